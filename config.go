@@ -9,9 +9,83 @@ import (
 )
 
 type Endpoint struct {
-	Host string `json:"host"    yaml:"host"    toml:"host"    mapstructure:"host"`
-	API  int    `json:"apiPort" yaml:"apiPort" toml:"apiPort" mapstructure:"apiPort"`
-	WEB  int    `json:"webPort" yaml:"webPort" toml:"webPort" mapstructure:"webPort"`
+	Host string         `json:"host"     yaml:"host"     toml:"host"     mapstructure:"host"`
+	API  int            `json:"apiPort"  yaml:"apiPort"  toml:"apiPort"  mapstructure:"apiPort"`
+	WEB  int            `json:"webPort"  yaml:"webPort"  toml:"webPort"  mapstructure:"webPort"`
+	TCP  int            `json:"tcpPort"  yaml:"tcpPort"  toml:"tcpPort"  mapstructure:"tcpPort"`
+	UDP  map[string]int `json:"udpPorts" yaml:"udpPorts" toml:"udpPorts" mapstructure:"udpPorts"`
+
+	PollInterval string `json:"pollInterval" yaml:"pollInterval" toml:"pollInterval" mapstructure:"pollInterval"`
+	Timeout      string `json:"timeout"      yaml:"timeout"      toml:"timeout"      mapstructure:"timeout"`
+	MaxBackoff   string `json:"maxBackoff"   yaml:"maxBackoff"   toml:"maxBackoff"   mapstructure:"maxBackoff"`
+
+	APIScheme string        `json:"apiScheme" yaml:"apiScheme" toml:"apiScheme" mapstructure:"apiScheme"`
+	TLS       *EndpointTLS  `json:"tls"       yaml:"tls"       toml:"tls"       mapstructure:"tls"`
+	Auth      *EndpointAuth `json:"auth"      yaml:"auth"      toml:"auth"      mapstructure:"auth"`
+
+	WebSecure int          `json:"webSecurePort" yaml:"webSecurePort" toml:"webSecurePort" mapstructure:"webSecurePort"`
+	WebTLS    *EndpointTLS `json:"webTLS"        yaml:"webTLS"        toml:"webTLS"        mapstructure:"webTLS"`
+
+	APIURL  string `json:"apiURL"  yaml:"apiURL"  toml:"apiURL"  mapstructure:"apiURL"`
+	WebURL  string `json:"webURL"  yaml:"webURL"  toml:"webURL"  mapstructure:"webURL"`
+	RawPath string `json:"rawPath" yaml:"rawPath" toml:"rawPath" mapstructure:"rawPath"`
+
+	Filter       *Filter `json:"filter"       yaml:"filter"       toml:"filter"       mapstructure:"filter"`
+	ExportMarker string  `json:"exportMarker" yaml:"exportMarker" toml:"exportMarker" mapstructure:"exportMarker"`
+
+	PriorityOffset    int               `json:"priorityOffset"    yaml:"priorityOffset"    toml:"priorityOffset"    mapstructure:"priorityOffset"`
+	EntryPoints       map[string]string `json:"entryPoints"       yaml:"entryPoints"       toml:"entryPoints"       mapstructure:"entryPoints"`
+	DefaultEntryPoint string            `json:"defaultEntryPoint" yaml:"defaultEntryPoint" toml:"defaultEntryPoint" mapstructure:"defaultEntryPoint"`
+
+	Weight int `json:"weight" yaml:"weight" toml:"weight" mapstructure:"weight"`
+
+	Domains     []string `json:"domains"     yaml:"domains"     toml:"domains"     mapstructure:"domains"`
+	StrictHosts bool     `json:"strictHosts" yaml:"strictHosts" toml:"strictHosts" mapstructure:"strictHosts"`
+
+	WildcardDomains []string `json:"wildcardDomains" yaml:"wildcardDomains" toml:"wildcardDomains" mapstructure:"wildcardDomains"`
+}
+
+type FilterRules struct {
+	Providers   []string `json:"providers"   yaml:"providers"   toml:"providers"   mapstructure:"providers"`
+	Routers     []string `json:"routers"     yaml:"routers"     toml:"routers"     mapstructure:"routers"`
+	EntryPoints []string `json:"entryPoints" yaml:"entryPoints" toml:"entryPoints" mapstructure:"entryPoints"`
+	Hosts       []string `json:"hosts"       yaml:"hosts"       toml:"hosts"       mapstructure:"hosts"`
+}
+
+type Filter struct {
+	Include *FilterRules `json:"include" yaml:"include" toml:"include" mapstructure:"include"`
+	Exclude *FilterRules `json:"exclude" yaml:"exclude" toml:"exclude" mapstructure:"exclude"`
+}
+
+type EndpointAuth struct {
+	Username string            `json:"username" yaml:"username" toml:"username" mapstructure:"username"`
+	Password string            `json:"password" yaml:"password" toml:"password" mapstructure:"password"`
+	Token    string            `json:"token"    yaml:"token"    toml:"token"    mapstructure:"token"`
+	Headers  map[string]string `json:"headers"  yaml:"headers"  toml:"headers"  mapstructure:"headers"`
+}
+
+type EndpointTLS struct {
+	CA                 string `json:"ca"                 yaml:"ca"                 toml:"ca"                 mapstructure:"ca"`
+	Cert               string `json:"cert"               yaml:"cert"               toml:"cert"               mapstructure:"cert"`
+	Key                string `json:"key"                yaml:"key"                toml:"key"                mapstructure:"key"`
+	ServerName         string `json:"serverName"         yaml:"serverName"         toml:"serverName"         mapstructure:"serverName"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify" yaml:"insecureSkipVerify" toml:"insecureSkipVerify" mapstructure:"insecureSkipVerify"`
+}
+
+type TLSRule struct {
+	Suffix       string `json:"suffix"       yaml:"suffix"       toml:"suffix"       mapstructure:"suffix"`
+	Regex        string `json:"regex"        yaml:"regex"        toml:"regex"        mapstructure:"regex"`
+	CertResolver string `json:"certResolver" yaml:"certResolver" toml:"certResolver" mapstructure:"certResolver"`
+	Options      string `json:"options"      yaml:"options"      toml:"options"      mapstructure:"options"`
+}
+
+type Redirect struct {
+	Disabled    bool     `json:"disabled"    yaml:"disabled"    toml:"disabled"    mapstructure:"disabled"`
+	Name        string   `json:"name"        yaml:"name"        toml:"name"        mapstructure:"name"`
+	Permanent   *bool    `json:"permanent"   yaml:"permanent"   toml:"permanent"   mapstructure:"permanent"`
+	Port        string   `json:"port"        yaml:"port"        toml:"port"        mapstructure:"port"`
+	SecureOnly  bool     `json:"secureOnly"  yaml:"secureOnly"  toml:"secureOnly"  mapstructure:"secureOnly"`
+	EntryPoints []string `json:"entryPoints" yaml:"entryPoints" toml:"entryPoints" mapstructure:"entryPoints"`
 }
 
 type Config struct {
@@ -19,12 +93,156 @@ type Config struct {
 	PollInterval string     `json:"pollInterval" yaml:"pollInterval" toml:"pollInterval" mapstructure:"pollInterval"`
 	Endpoints    []Endpoint `json:"endpoints"    yaml:"endpoints"    toml:"endpoints"    mapstructure:"endpoints"`
 	TLSResolver  *string    `json:"tlsResolver"  yaml:"tlsResolver"  toml:"tlsResolver"  mapstructure:"tlsResolver"`
+	TLSOptions   string     `json:"tlsOptions"   yaml:"tlsOptions"   toml:"tlsOptions"   mapstructure:"tlsOptions"`
+	TLSRules     []TLSRule  `json:"tlsRules"     yaml:"tlsRules"     toml:"tlsRules"     mapstructure:"tlsRules"`
+	Redirect     *Redirect  `json:"redirect"     yaml:"redirect"     toml:"redirect"     mapstructure:"redirect"`
+	MaxStaleness string     `json:"maxStaleness" yaml:"maxStaleness" toml:"maxStaleness" mapstructure:"maxStaleness"`
+	Lazy         bool       `json:"lazy"         yaml:"lazy"         toml:"lazy"         mapstructure:"lazy"`
+	MinHealthy   int        `json:"minHealthy"   yaml:"minHealthy"   toml:"minHealthy"   mapstructure:"minHealthy"`
+	Filter       *Filter    `json:"filter"       yaml:"filter"       toml:"filter"       mapstructure:"filter"`
+	ExportMarker string     `json:"exportMarker" yaml:"exportMarker" toml:"exportMarker" mapstructure:"exportMarker"`
+
+	CopyMiddlewares bool `json:"copyMiddlewares" yaml:"copyMiddlewares" toml:"copyMiddlewares" mapstructure:"copyMiddlewares"`
+	WeightByServers bool `json:"weightByServers" yaml:"weightByServers" toml:"weightByServers" mapstructure:"weightByServers"`
+
+	Aggregate      bool   `json:"aggregate"      yaml:"aggregate"      toml:"aggregate"      mapstructure:"aggregate"`
+	StickyCookie   string `json:"stickyCookie"   yaml:"stickyCookie"   toml:"stickyCookie"   mapstructure:"stickyCookie"`
+	ConflictPolicy string `json:"conflictPolicy" yaml:"conflictPolicy" toml:"conflictPolicy" mapstructure:"conflictPolicy"`
+
+	HostOwnership  bool   `json:"hostOwnership"  yaml:"hostOwnership"  toml:"hostOwnership"  mapstructure:"hostOwnership"`
+	OwnershipGrace string `json:"ownershipGrace" yaml:"ownershipGrace" toml:"ownershipGrace" mapstructure:"ownershipGrace"`
+
+	TLSDomains      bool     `json:"tlsDomains"      yaml:"tlsDomains"      toml:"tlsDomains"      mapstructure:"tlsDomains"`
+	WildcardDomains []string `json:"wildcardDomains" yaml:"wildcardDomains" toml:"wildcardDomains" mapstructure:"wildcardDomains"`
+
+	EntryPoints       map[string]string `json:"entryPoints"       yaml:"entryPoints"       toml:"entryPoints"       mapstructure:"entryPoints"`
+	DefaultEntryPoint string            `json:"defaultEntryPoint" yaml:"defaultEntryPoint" toml:"defaultEntryPoint" mapstructure:"defaultEntryPoint"`
 
 	*internal.Config `mapstructure:"-"`
 }
 
 func CreateConfig() *Config { return new(Config) }
 
+func parseDuration(val string) (time.Duration, error) {
+	if val == "" {
+		return 0, nil
+	}
+
+	return time.ParseDuration(val)
+}
+
+func (r *FilterRules) convert() *internal.FilterRules {
+	if r == nil {
+		return nil
+	}
+
+	return &internal.FilterRules{
+		Providers:   r.Providers,
+		Routers:     r.Routers,
+		EntryPoints: r.EntryPoints,
+		Hosts:       r.Hosts,
+	}
+}
+
+func (f *Filter) convert() *internal.Filter {
+	if f == nil {
+		return nil
+	}
+
+	return &internal.Filter{Include: f.Include.convert(), Exclude: f.Exclude.convert()}
+}
+
+func convertTLSRules(items []TLSRule) []internal.TLSRule {
+	out := make([]internal.TLSRule, 0, len(items))
+	for _, item := range items {
+		out = append(out, internal.TLSRule(item))
+	}
+
+	return out
+}
+
+func (r *Redirect) convert() *internal.Redirect {
+	if r == nil {
+		return nil
+	}
+
+	out := internal.Redirect(*r)
+
+	return &out
+}
+
+func (t *EndpointTLS) convert() *internal.EndpointTLS {
+	if t == nil {
+		return nil
+	}
+
+	return &internal.EndpointTLS{
+		CA:                 t.CA,
+		Cert:               t.Cert,
+		Key:                t.Key,
+		ServerName:         t.ServerName,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+}
+
+func (e Endpoint) prepare(i int) (internal.Endpoint, error) {
+	out := internal.Endpoint{
+		Host: e.Host,
+		API:  e.API,
+		WEB:  e.WEB,
+		TCP:  e.TCP,
+		UDP:  e.UDP,
+
+		APIScheme: e.APIScheme,
+		TLS:       e.TLS.convert(),
+		APIURL:    e.APIURL,
+		WebURL:    e.WebURL,
+		RawPath:   e.RawPath,
+		Filter:    e.Filter.convert(),
+
+		ExportMarker: e.ExportMarker,
+
+		PriorityOffset:    e.PriorityOffset,
+		EntryPoints:       e.EntryPoints,
+		DefaultEntryPoint: e.DefaultEntryPoint,
+
+		Weight: e.Weight,
+
+		Domains:     e.Domains,
+		StrictHosts: e.StrictHosts,
+
+		WildcardDomains: e.WildcardDomains,
+
+		WebSecure: e.WebSecure,
+		WebTLS:    e.WebTLS.convert(),
+	}
+
+	if e.Auth != nil {
+		out.Auth = &internal.EndpointAuth{
+			Username: e.Auth.Username,
+			Password: e.Auth.Password,
+			Token:    e.Auth.Token,
+			Headers:  e.Auth.Headers,
+		}
+	}
+
+	// addresses and ports are checked by internal.Config.Validate
+	var err error
+	if out.PollInterval, err = parseDuration(e.PollInterval); err != nil {
+		return out, fmt.Errorf("wrong #%d endpoint poll interval(%q): %w", i, e.PollInterval, err)
+	}
+
+	if out.Timeout, err = parseDuration(e.Timeout); err != nil {
+		return out, fmt.Errorf("wrong #%d endpoint timeout(%q): %w", i, e.Timeout, err)
+	}
+
+	if out.MaxBackoff, err = parseDuration(e.MaxBackoff); err != nil {
+		return out, fmt.Errorf("wrong #%d endpoint max backoff(%q): %w", i, e.MaxBackoff, err)
+	}
+
+	return out, nil
+}
+
 func (c *Config) validate() error {
 	if c == nil {
 		return errors.New("empty config")
@@ -41,33 +259,48 @@ func (c *Config) validate() error {
 		return fmt.Errorf("wrong poll interval(%q): %w", c.PollInterval, err)
 	}
 
+	if c.Config.MaxStaleness, err = parseDuration(c.MaxStaleness); err != nil {
+		return fmt.Errorf("wrong max staleness(%q): %w", c.MaxStaleness, err)
+	}
+
+	if c.Config.OwnershipGrace, err = parseDuration(c.OwnershipGrace); err != nil {
+		return fmt.Errorf("wrong ownership grace(%q): %w", c.OwnershipGrace, err)
+	}
+
 	if len(c.Endpoints) == 0 {
 		return fmt.Errorf("empty endpoints: %d", len(c.Endpoints))
 	}
 
 	for i, endpoint := range c.Endpoints {
-		if endpoint.Host == "" {
-			return fmt.Errorf("empty #%d endpoint host", i)
+		item, err := endpoint.prepare(i)
+		if err != nil {
+			return err
 		}
 
-		if endpoint.API <= 0 {
-			return fmt.Errorf("empty #%d endpoint apiPort: %d", i, endpoint.API)
-		}
-
-		if endpoint.WEB <= 0 {
-			return fmt.Errorf("empty #%d endpoint webPort: %d", i, endpoint.WEB)
-		}
-
-		c.Config.Endpoints = append(c.Config.Endpoints, internal.Endpoint{
-			Host: endpoint.Host,
-			API:  endpoint.API,
-			WEB:  endpoint.WEB,
-		})
+		c.Config.Endpoints = append(c.Config.Endpoints, item)
 	}
 
 	if c.TLSResolver != nil {
 		c.Config.TLSResolver = c.TLSResolver
 	}
 
+	c.Config.Lazy = c.Lazy
+	c.Config.MinHealthy = c.MinHealthy
+	c.Config.Filter = c.Filter.convert()
+	c.Config.ExportMarker = c.ExportMarker
+	c.Config.CopyMiddlewares = c.CopyMiddlewares
+	c.Config.WeightByServers = c.WeightByServers
+	c.Config.Aggregate = c.Aggregate
+	c.Config.StickyCookie = c.StickyCookie
+	c.Config.ConflictPolicy = c.ConflictPolicy
+	c.Config.HostOwnership = c.HostOwnership
+	c.Config.TLSOptions = c.TLSOptions
+	c.Config.TLSRules = convertTLSRules(c.TLSRules)
+	c.Config.Redirect = c.Redirect.convert()
+	c.Config.TLSDomains = c.TLSDomains
+	c.Config.WildcardDomains = c.WildcardDomains
+	c.Config.EntryPoints = c.EntryPoints
+	c.Config.DefaultEntryPoint = c.DefaultEntryPoint
+
 	return c.Validate()
 }
@@ -2,6 +2,7 @@ package traefik_provider
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -17,6 +18,14 @@ func TestConfig_validate(t *testing.T) {
 	require.ErrorContains(t, cfg.validate(), "time: invalid duration", "empty pollTimeout")
 
 	cfg.PollInterval = "5s"
+	cfg.MaxStaleness = "forever"
+	require.ErrorContains(t, cfg.validate(), "wrong max staleness", "wrong maxStaleness")
+
+	cfg.MaxStaleness = "1m"
+	cfg.OwnershipGrace = "a while"
+	require.ErrorContains(t, cfg.validate(), "wrong ownership grace", "wrong ownershipGrace")
+
+	cfg.OwnershipGrace = "10m"
 	require.ErrorContains(t, cfg.validate(), "empty endpoints", "empty endpoints")
 
 	cfg.Endpoints = make([]Endpoint, 1)
@@ -27,4 +36,30 @@ func TestConfig_validate(t *testing.T) {
 
 	cfg.Endpoints[0].API = 8080
 	require.ErrorContains(t, cfg.validate(), "empty #0 endpoint webPort")
+
+	cfg.Endpoints[0].WEB = 8080
+	cfg.Endpoints[0].PollInterval = "often"
+	require.ErrorContains(t, cfg.validate(), "wrong #0 endpoint poll interval")
+
+	cfg.Endpoints[0].PollInterval = "1s"
+	cfg.Endpoints[0].Timeout = "fast"
+	require.ErrorContains(t, cfg.validate(), "wrong #0 endpoint timeout")
+
+	cfg.Endpoints[0].Timeout = "1s"
+	cfg.Endpoints[0].MaxBackoff = "long"
+	require.ErrorContains(t, cfg.validate(), "wrong #0 endpoint max backoff")
+
+	cfg.Endpoints[0].MaxBackoff = "1m"
+	cfg.Endpoints[0].TLS = &EndpointTLS{CA: "missing.pem"}
+	require.ErrorContains(t, cfg.validate(), "wrong #0 endpoint tls: requires apiScheme")
+
+	cfg.Endpoints[0].APIScheme = "https"
+	require.ErrorContains(t, cfg.validate(), "could not read CA bundle")
+
+	cfg.Endpoints[0].APIScheme = ""
+	cfg.Endpoints[0].TLS = nil
+	require.NoError(t, cfg.validate())
+	require.Equal(t, time.Second, cfg.Config.Endpoints[0].PollInterval)
+	require.Equal(t, time.Second, cfg.Config.Endpoints[0].Timeout)
+	require.Equal(t, time.Minute, cfg.Config.Endpoints[0].MaxBackoff)
 }
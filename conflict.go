@@ -0,0 +1,214 @@
+package traefik_provider
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/traefik/genconf/dynamic"
+
+	"github.com/im-kulikov/traefik-provider/internal"
+)
+
+// route is a generated router checked for conflicts with other endpoints.
+type route struct {
+	index       int
+	kind        string
+	name        string
+	rule        string
+	tls         bool
+	entryPoints []string
+	priority    int
+	hosts       []string
+}
+
+// overlaps reports whether routes of different endpoints claim the same requests:
+// the same kind of traffic on a common entrypoint, with the same rule or a common host.
+func (r route) overlaps(o route) bool {
+	switch {
+	case r.index == o.index, r.kind != o.kind, r.tls != o.tls:
+		return false
+	case len(r.entryPoints) > 0 && len(o.entryPoints) > 0 &&
+		!slices.ContainsFunc(r.entryPoints, func(val string) bool { return slices.Contains(o.entryPoints, val) }):
+		return false
+	case r.rule == o.rule:
+		return true
+	}
+
+	return slices.ContainsFunc(r.hosts, func(val string) bool { return slices.Contains(o.hosts, val) })
+}
+
+// drop removes the route from the merged configuration.
+func (r route) drop(val *dynamic.Configuration) {
+	switch r.kind {
+	case "tcp router":
+		delete(val.TCP.Routers, r.name)
+	case "udp router":
+		delete(val.UDP.Routers, r.name)
+	default:
+		delete(val.HTTP.Routers, r.name)
+	}
+}
+
+// effective returns the priority Traefik applies to the route.
+func (r route) effective() int {
+	if r.priority == 0 {
+		return len(r.rule)
+	}
+
+	return r.priority
+}
+
+// collectRoutes returns the routers of results that made it into val, ordered by endpoint.
+func collectRoutes(val dynamic.Configuration, results []*dynamic.Configuration) []route {
+	var out []route
+	for i, msg := range results {
+		if msg == nil {
+			continue
+		}
+
+		if msg.HTTP != nil && val.HTTP != nil {
+			for name, item := range msg.HTTP.Routers {
+				if val.HTTP.Routers[name] == item {
+					out = append(out, route{
+						index:       i,
+						kind:        "router",
+						name:        name,
+						rule:        item.Rule,
+						tls:         item.TLS != nil,
+						entryPoints: item.EntryPoints,
+						priority:    item.Priority,
+						hosts:       internal.RuleHosts(item.Rule),
+					})
+				}
+			}
+		}
+
+		if msg.TCP != nil && val.TCP != nil {
+			for name, item := range msg.TCP.Routers {
+				if val.TCP.Routers[name] == item {
+					out = append(out, route{
+						index:       i,
+						kind:        "tcp router",
+						name:        name,
+						rule:        item.Rule,
+						tls:         item.TLS != nil,
+						entryPoints: item.EntryPoints,
+						priority:    item.Priority,
+						hosts:       internal.RuleHosts(item.Rule),
+					})
+				}
+			}
+		}
+
+		// UDP routers have no rule, so routers sharing an entrypoint always overlap
+		if msg.UDP != nil && val.UDP != nil {
+			for name, item := range msg.UDP.Routers {
+				if val.UDP.Routers[name] == item {
+					out = append(out, route{index: i, kind: "udp router", name: name, entryPoints: item.EntryPoints})
+				}
+			}
+		}
+	}
+
+	return out
+}
+
+// conflictNames reports entries generated under the same name by several
+// endpoints. The merge keeps the entry of the endpoint listed first.
+func conflictNames(names []string, results []*dynamic.Configuration) []string {
+	var out []string
+
+	owners := make(map[string]int)
+	seen := make(map[string][]byte)
+	for i, msg := range results {
+		items, err := flatten(msg)
+		if err != nil {
+			out = append(out, err.Error())
+
+			continue
+		}
+
+		for key, item := range items {
+			if prev, ok := seen[key]; !ok {
+				seen[key], owners[key] = item, i
+			} else if !bytes.Equal(prev, item) {
+				out = append(out, fmt.Sprintf(
+					"conflict on %s between clients %q and %q: keep the first one",
+					key,
+					names[owners[key]],
+					names[i],
+				))
+			}
+		}
+	}
+
+	return out
+}
+
+// resolveConflicts applies the conflict policy to overlapping routes of different endpoints.
+func (m *merger) resolveConflicts(
+	val *dynamic.Configuration,
+	names []string,
+	results []*dynamic.Configuration,
+) []string {
+	var out []string
+
+	routes := collectRoutes(*val, results)
+	drop := make([]bool, len(routes))
+	for i, prev := range routes {
+		for j := i + 1; j < len(routes); j++ {
+			next := routes[j]
+			if !prev.overlaps(next) {
+				continue
+			}
+
+			action := "keep both"
+			switch m.policy {
+			case internal.ConflictFirst:
+				drop[j], action = true, fmt.Sprintf("drop %q", next.name)
+			case internal.ConflictPriority:
+				if next.effective() > prev.effective() {
+					drop[i], action = true, fmt.Sprintf("drop %q", prev.name)
+				} else {
+					drop[j], action = true, fmt.Sprintf("drop %q", next.name)
+				}
+			case internal.ConflictReject:
+				drop[i], drop[j], action = true, true, "drop both"
+			}
+
+			out = append(out, fmt.Sprintf(
+				"conflict between %s %q(client:%q, rule:%q) and %q(client:%q, rule:%q): %s",
+				prev.kind,
+				prev.name,
+				names[prev.index],
+				prev.rule,
+				next.name,
+				names[next.index],
+				next.rule,
+				action,
+			))
+		}
+	}
+
+	for i, item := range routes {
+		if drop[i] {
+			item.drop(val)
+		}
+	}
+
+	return out
+}
+
+// report logs conflicts that were not reported by the previous merge,
+// so a lasting conflict is not repeated on every poll.
+func (m *merger) report(conflicts []string) {
+	for _, msg := range conflicts {
+		if !slices.Contains(m.conflicts, msg) {
+			log.Print(msg)
+		}
+	}
+
+	m.conflicts = conflicts
+}
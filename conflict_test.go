@@ -0,0 +1,132 @@
+package traefik_provider
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/genconf/dynamic"
+
+	"github.com/im-kulikov/traefik-provider/internal"
+)
+
+func routerConfig(name, rule string, priority int) *dynamic.Configuration {
+	return &dynamic.Configuration{HTTP: &dynamic.HTTPConfiguration{
+		Routers:  map[string]*dynamic.Router{name: {Service: name, Rule: rule, Priority: priority}},
+		Services: map[string]*dynamic.Service{name: {}},
+	}}
+}
+
+func TestRoute_overlaps(t *testing.T) {
+	base := route{index: 0, kind: "router", rule: "Host(`a`)", hosts: []string{"a"}}
+
+	other := base
+	other.index = 1
+	require.True(t, base.overlaps(other))
+
+	other.rule, other.hosts = "Host(`a`) && PathPrefix(`/api`)", []string{"a"}
+	require.True(t, base.overlaps(other), "common host")
+
+	other.rule, other.hosts = "Host(`b`)", []string{"b"}
+	require.False(t, base.overlaps(other))
+
+	other.rule, other.hosts = base.rule, base.hosts
+	other.tls = true
+	require.False(t, base.overlaps(other), "plain and TLS routers do not compete")
+
+	other.tls = false
+	other.kind = "tcp router"
+	require.False(t, base.overlaps(other))
+
+	other.kind = "router"
+	base.entryPoints, other.entryPoints = []string{"web"}, []string{"lan"}
+	require.False(t, base.overlaps(other), "different entrypoints")
+
+	other.entryPoints = nil
+	require.True(t, base.overlaps(other), "no entrypoints means all of them")
+
+	other.index = 0
+	require.False(t, base.overlaps(other), "same endpoint")
+}
+
+func TestMerger_conflicts(t *testing.T) {
+	names := []string{"one", "two", "three"}
+	results := []*dynamic.Configuration{
+		routerConfig("app-one", "Host(`app.example.com`)", 0),
+		routerConfig("app-two", "Host(`app.example.com`) && PathPrefix(`/api`)", 0),
+		routerConfig("app-three", "Host(`other.example.com`)", 0),
+	}
+
+	cases := []struct {
+		policy string
+		keep   []string
+	}{
+		{"", []string{"app-one", "app-two", "app-three"}},
+		{internal.ConflictMerge, []string{"app-one", "app-two", "app-three"}},
+		{internal.ConflictFirst, []string{"app-one", "app-three"}},
+		{internal.ConflictPriority, []string{"app-two", "app-three"}},
+		{internal.ConflictReject, []string{"app-three"}},
+	}
+
+	for _, item := range cases {
+		merge := &merger{policy: item.policy, weights: []int{1, 1, 1}}
+
+		val := merge.merge(names, results)
+		require.Len(t, val.HTTP.Routers, len(item.keep), item.policy)
+		for _, name := range item.keep {
+			require.Contains(t, val.HTTP.Routers, name, item.policy)
+		}
+
+		require.Len(t, merge.conflicts, 1, item.policy)
+		require.Contains(t, merge.conflicts[0], "conflict between router \"app-one\"", item.policy)
+	}
+
+	results[1] = routerConfig("app-two", "Host(`app.example.com`)", 1)
+	merge := &merger{policy: internal.ConflictPriority, weights: []int{1, 1, 1}}
+	val := merge.merge(names, results)
+	require.Contains(t, val.HTTP.Routers, "app-one", "explicit priority 1 loses to the rule length")
+	require.NotContains(t, val.HTTP.Routers, "app-two")
+}
+
+func TestMerger_udpConflicts(t *testing.T) {
+	udpConfig := func(name, entrypoint string) *dynamic.Configuration {
+		return &dynamic.Configuration{UDP: &dynamic.UDPConfiguration{
+			Routers:  map[string]*dynamic.UDPRouter{name: {EntryPoints: []string{entrypoint}, Service: name}},
+			Services: map[string]*dynamic.UDPService{name: {}},
+		}}
+	}
+
+	names := []string{"one", "two", "three"}
+	results := []*dynamic.Configuration{
+		udpConfig("dns-dns-one", "dns"),
+		udpConfig("dns-dns-two", "dns"),
+		udpConfig("syslog-syslog-three", "syslog"),
+	}
+
+	merge := &merger{policy: internal.ConflictFirst, weights: []int{1, 1, 1}}
+	val := merge.merge(names, results)
+	require.Len(t, val.UDP.Routers, 2)
+	require.Contains(t, val.UDP.Routers, "dns-dns-one")
+	require.Contains(t, val.UDP.Routers, "syslog-syslog-three")
+	require.Equal(t, []string{
+		`conflict between udp router "dns-dns-one"(client:"one", rule:"") and "dns-dns-two"(client:"two", rule:""): ` +
+			`drop "dns-dns-two"`,
+	}, merge.conflicts)
+}
+
+func TestConflictNames(t *testing.T) {
+	names := []string{"one", "two"}
+	results := []*dynamic.Configuration{
+		routerConfig("app-host", "Host(`a`)", 0),
+		routerConfig("app-host", "Host(`b`)", 0),
+	}
+
+	require.Equal(t, []string{
+		`conflict on router app-host between clients "one" and "two": keep the first one`,
+	}, conflictNames(names, results))
+
+	var merge *merger
+	require.Equal(t, "Host(`a`)", merge.merge(names, results).HTTP.Routers["app-host"].Rule)
+
+	results[1] = routerConfig("app-host", "Host(`a`)", 0)
+	require.Empty(t, conflictNames(names, results))
+}
@@ -0,0 +1,170 @@
+package traefik_provider
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/traefik/genconf/dynamic"
+)
+
+// emitter merges per-endpoint results and pushes them to Traefik
+// only when the merged configuration differs from the previous one.
+type emitter struct {
+	out   chan<- json.Marshaler
+	merge *merger
+
+	fingerprint []byte
+	endpoints   map[string]map[string][]byte
+}
+
+type changes struct {
+	added   []string
+	removed []string
+	changed []string
+}
+
+func newEmitter(out chan<- json.Marshaler, merge *merger) *emitter {
+	return &emitter{out: out, merge: merge, endpoints: make(map[string]map[string][]byte)}
+}
+
+// emit merges results (ordered as names) and sends them when something changed.
+func (e *emitter) emit(names []string, results []*dynamic.Configuration) (bool, error) {
+	val := e.merge.merge(names, results)
+
+	// encoding/json sorts map keys, so the payload is a stable fingerprint
+	data, err := json.Marshal(val)
+	if err != nil {
+		return false, fmt.Errorf("could not fingerprint config: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+	if e.fingerprint != nil && bytes.Equal(e.fingerprint, sum[:]) {
+		return false, nil
+	}
+
+	state := make(map[string]map[string][]byte, len(names))
+	for i, name := range names {
+		if state[name], err = flatten(results[i]); err != nil {
+			return false, err
+		}
+	}
+
+	if e.fingerprint != nil {
+		e.report(state)
+	}
+
+	e.fingerprint, e.endpoints = sum[:], state
+	e.out <- dynamic.JSONPayload{Configuration: &val}
+
+	return true, nil
+}
+
+func (e *emitter) report(state map[string]map[string][]byte) {
+	names := make([]string, 0, len(state))
+	for name := range state {
+		names = append(names, name)
+	}
+
+	for name := range e.endpoints {
+		if _, ok := state[name]; !ok {
+			names = append(names, name)
+		}
+	}
+
+	slices.Sort(names)
+
+	for _, name := range names {
+		if diff := compare(e.endpoints[name], state[name]); diff.String() != "" {
+			log.Printf("config changed(client:%q): %s", name, diff)
+		}
+	}
+}
+
+func compare(prev, next map[string][]byte) changes {
+	var diff changes
+	for key, item := range next {
+		if old, ok := prev[key]; !ok {
+			diff.added = append(diff.added, key)
+		} else if !bytes.Equal(old, item) {
+			diff.changed = append(diff.changed, key)
+		}
+	}
+
+	for key := range prev {
+		if _, ok := next[key]; !ok {
+			diff.removed = append(diff.removed, key)
+		}
+	}
+
+	slices.Sort(diff.added)
+	slices.Sort(diff.removed)
+	slices.Sort(diff.changed)
+
+	return diff
+}
+
+func (c changes) String() string {
+	var parts []string
+	if len(c.added) > 0 {
+		parts = append(parts, fmt.Sprintf("added %s", strings.Join(c.added, ", ")))
+	}
+
+	if len(c.removed) > 0 {
+		parts = append(parts, fmt.Sprintf("removed %s", strings.Join(c.removed, ", ")))
+	}
+
+	if len(c.changed) > 0 {
+		parts = append(parts, fmt.Sprintf("changed %s", strings.Join(c.changed, ", ")))
+	}
+
+	return strings.Join(parts, "; ")
+}
+
+// flatten encodes every router and service of cfg under a "kind name" key.
+func flatten(cfg *dynamic.Configuration) (map[string][]byte, error) {
+	out := make(map[string][]byte)
+	if cfg == nil {
+		return out, nil
+	}
+
+	sections := make(map[string]any)
+	if cfg.HTTP != nil {
+		sections["router"] = cfg.HTTP.Routers
+		sections["service"] = cfg.HTTP.Services
+		sections["middleware"] = cfg.HTTP.Middlewares
+		sections["servers transport"] = cfg.HTTP.ServersTransports
+	}
+
+	if cfg.TCP != nil {
+		sections["tcp router"] = cfg.TCP.Routers
+		sections["tcp service"] = cfg.TCP.Services
+	}
+
+	if cfg.UDP != nil {
+		sections["udp router"] = cfg.UDP.Routers
+		sections["udp service"] = cfg.UDP.Services
+	}
+
+	for kind, section := range sections {
+		data, err := json.Marshal(section)
+		if err != nil {
+			return nil, fmt.Errorf("could not encode %ss: %w", kind, err)
+		}
+
+		var items map[string]json.RawMessage
+		if err = json.Unmarshal(data, &items); err != nil {
+			return nil, fmt.Errorf("could not decode %ss: %w", kind, err)
+		}
+
+		for name, item := range items {
+			out[kind+" "+name] = item
+		}
+	}
+
+	return out, nil
+}
@@ -0,0 +1,58 @@
+package traefik_provider
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/genconf/dynamic"
+)
+
+func testConfig(rule string) *dynamic.Configuration {
+	return &dynamic.Configuration{HTTP: &dynamic.HTTPConfiguration{
+		Routers:  map[string]*dynamic.Router{"whoami-host": {Service: "whoami-host", Rule: rule}},
+		Services: map[string]*dynamic.Service{"whoami-host": {}},
+	}}
+}
+
+func TestEmitter(t *testing.T) {
+	out := make(chan json.Marshaler, 10)
+	emit := newEmitter(out, nil)
+
+	names := []string{"host", "other"}
+
+	ok, err := emit.emit(names, []*dynamic.Configuration{nil, nil})
+	require.NoError(t, err)
+	require.True(t, ok, "first result is always emitted")
+
+	ok, err = emit.emit(names, []*dynamic.Configuration{nil, nil})
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	ok, err = emit.emit(names, []*dynamic.Configuration{testConfig("Host(`a`)"), nil})
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = emit.emit(names, []*dynamic.Configuration{testConfig("Host(`a`)"), nil})
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	ok, err = emit.emit(names, []*dynamic.Configuration{testConfig("Host(`b`)"), nil})
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	require.Len(t, out, 3)
+}
+
+func TestCompare(t *testing.T) {
+	prev, err := flatten(testConfig("Host(`a`)"))
+	require.NoError(t, err)
+
+	next, err := flatten(testConfig("Host(`b`)"))
+	require.NoError(t, err)
+
+	require.Empty(t, compare(prev, prev).String())
+	require.Equal(t, "changed router whoami-host", compare(prev, next).String())
+	require.Equal(t, "added router whoami-host, service whoami-host", compare(nil, next).String())
+	require.Equal(t, "removed router whoami-host, service whoami-host", compare(next, nil).String())
+}
@@ -0,0 +1,120 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const (
+	secretEnv  = "env:"
+	secretFile = "file:"
+	redacted   = "[REDACTED]"
+)
+
+// EndpointAuth describes the credentials sent to a remote Traefik API.
+// Password, Token and header values may reference a secret
+// as "env:NAME" or "file:/path" instead of holding it inline.
+type EndpointAuth struct {
+	Username string            `json:"username" yaml:"username" toml:"username" mapstructure:"username"`
+	Password string            `json:"password" yaml:"password" toml:"password" mapstructure:"password"`
+	Token    string            `json:"token"    yaml:"token"    toml:"token"    mapstructure:"token"`
+	Headers  map[string]string `json:"headers"  yaml:"headers"  toml:"headers"  mapstructure:"headers"`
+}
+
+func resolveSecret(val string) (string, error) {
+	switch {
+	case strings.HasPrefix(val, secretEnv):
+		name := strings.TrimPrefix(val, secretEnv)
+		if out, ok := os.LookupEnv(name); ok {
+			return out, nil
+		}
+
+		return "", fmt.Errorf("environment variable %q is not set", name)
+	case strings.HasPrefix(val, secretFile):
+		name := strings.TrimPrefix(val, secretFile)
+
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return "", fmt.Errorf("could not read secret file %q: %w", name, err)
+		}
+
+		return strings.TrimSpace(string(data)), nil
+	default:
+		return val, nil
+	}
+}
+
+func (a *EndpointAuth) validate() error {
+	if a == nil {
+		return nil
+	}
+
+	if a.Username != "" && a.Token != "" {
+		return errors.New("basic auth and bearer token are mutually exclusive")
+	}
+
+	if a.Username == "" && a.Password != "" {
+		return errors.New("password requires username")
+	}
+
+	_, err := a.apply(new(http.Request))
+
+	return err
+}
+
+// apply sets the credentials on req and returns the secret values it used,
+// so they can be redacted from error messages.
+func (a *EndpointAuth) apply(req *http.Request) ([]string, error) {
+	if a == nil {
+		return nil, nil
+	}
+
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+
+	var secrets []string
+	for name, item := range a.Headers {
+		val, err := resolveSecret(item)
+		if err != nil {
+			return nil, fmt.Errorf("header %q: %w", name, err)
+		}
+
+		req.Header.Set(name, val)
+		secrets = append(secrets, val)
+	}
+
+	switch {
+	case a.Username != "":
+		password, err := resolveSecret(a.Password)
+		if err != nil {
+			return nil, fmt.Errorf("password: %w", err)
+		}
+
+		req.SetBasicAuth(a.Username, password)
+		secrets = append(secrets, password, strings.TrimPrefix(req.Header.Get("Authorization"), "Basic "))
+	case a.Token != "":
+		token, err := resolveSecret(a.Token)
+		if err != nil {
+			return nil, fmt.Errorf("token: %w", err)
+		}
+
+		req.Header.Set("Authorization", "Bearer "+token)
+		secrets = append(secrets, token)
+	}
+
+	return secrets, nil
+}
+
+func redact(text string, secrets []string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			text = strings.ReplaceAll(text, secret, redacted)
+		}
+	}
+
+	return text
+}
@@ -0,0 +1,138 @@
+package internal
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/genconf/dynamic"
+)
+
+func TestResolveSecret(t *testing.T) {
+	t.Setenv("TRAEFIK_PROVIDER_TEST_SECRET", "from-env")
+
+	path := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(path, []byte("from-file\n"), 0o600))
+
+	val, err := resolveSecret("inline")
+	require.NoError(t, err)
+	require.Equal(t, "inline", val)
+
+	val, err = resolveSecret("env:TRAEFIK_PROVIDER_TEST_SECRET")
+	require.NoError(t, err)
+	require.Equal(t, "from-env", val)
+
+	val, err = resolveSecret("file:" + path)
+	require.NoError(t, err)
+	require.Equal(t, "from-file", val)
+
+	_, err = resolveSecret("env:TRAEFIK_PROVIDER_TEST_MISSING")
+	require.ErrorContains(t, err, "is not set")
+
+	_, err = resolveSecret("file:" + path + ".missing")
+	require.ErrorContains(t, err, "could not read secret file")
+}
+
+func TestEndpointAuth_validate(t *testing.T) {
+	var auth *EndpointAuth
+	require.NoError(t, auth.validate())
+
+	require.ErrorContains(t, (&EndpointAuth{Username: "admin", Token: "t"}).validate(), "mutually exclusive")
+	require.ErrorContains(t, (&EndpointAuth{Password: "p"}).validate(), "password requires username")
+	require.ErrorContains(t, (&EndpointAuth{Token: "env:TRAEFIK_PROVIDER_TEST_MISSING"}).validate(), "token")
+	require.ErrorContains(t, (&EndpointAuth{
+		Headers: map[string]string{"X-Api-Key": "env:TRAEFIK_PROVIDER_TEST_MISSING"},
+	}).validate(), `header "X-Api-Key"`)
+	require.NoError(t, (&EndpointAuth{Username: "admin", Password: "secret"}).validate())
+}
+
+func TestEndpointAuth_apply(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	secrets, err := (&EndpointAuth{
+		Token:   "token",
+		Headers: map[string]string{"X-Api-Key": "key"},
+	}).apply(req)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"token", "key"}, secrets)
+	require.Equal(t, "Bearer token", req.Header.Get("Authorization"))
+	require.Equal(t, "key", req.Header.Get("X-Api-Key"))
+
+	secrets, err = (&EndpointAuth{Username: "admin", Password: "secret"}).apply(req)
+	require.NoError(t, err)
+	require.Contains(t, secrets, "secret")
+
+	user, password, ok := req.BasicAuth()
+	require.True(t, ok)
+	require.Equal(t, "admin", user)
+	require.Equal(t, "secret", password)
+
+	require.Equal(t, "a [REDACTED] b", redact("a secret b", secrets))
+}
+
+func TestClient_auth(t *testing.T) {
+	t.Setenv("TRAEFIK_PROVIDER_TEST_PASSWORD", "s3cr3t")
+
+	data, err := os.ReadFile("../fixtures/jaeger-api-rawdata.json")
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != defaultRawPath {
+			w.WriteHeader(http.StatusOK)
+
+			return
+		}
+
+		user, password, ok := r.BasicAuth()
+		if !ok || user != "admin" {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		} else if password != "s3cr3t" {
+			// misbehaving remote that echoes credentials back
+			w.WriteHeader(http.StatusForbidden)
+			assert.NoError(t, catchError(w.Write([]byte("wrong password "+password))))
+
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		assert.NoError(t, catchError(w.Write(data)))
+	}))
+
+	addr, ok := srv.Listener.Addr().(*net.TCPAddr)
+	require.True(t, ok)
+
+	cfg := Config{
+		ConnTimeout:  defaultTestConnTimeout,
+		PollInterval: defaultTestPollInterval,
+		Endpoints: []Endpoint{{
+			Host: addr.IP.String(),
+			API:  addr.Port,
+			WEB:  addr.Port,
+			Auth: &EndpointAuth{Username: "admin", Password: "env:TRAEFIK_PROVIDER_TEST_PASSWORD"},
+		}},
+	}
+
+	require.NoError(t, cfg.Validate())
+
+	cli, err := cfg.PrepareClients(t.Context())
+	require.NoError(t, err)
+
+	out := make(chan *dynamic.Configuration, 1)
+	require.NoError(t, cli[0].FetchRaw(t.Context(), out))
+	require.NotNil(t, (<-out).HTTP)
+
+	t.Setenv("TRAEFIK_PROVIDER_TEST_PASSWORD", "leaked")
+
+	err = cli[0].FetchRaw(t.Context(), out)
+	require.Error(t, err)
+	require.NotContains(t, err.Error(), "leaked")
+	require.Contains(t, err.Error(), redacted)
+	require.Nil(t, <-out)
+}
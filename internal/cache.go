@@ -0,0 +1,45 @@
+package internal
+
+import (
+	"sync"
+	"time"
+
+	"github.com/traefik/genconf/dynamic"
+)
+
+// lastKnown keeps the last successful translation of an endpoint,
+// so a temporary failure does not withdraw its routes immediately.
+type lastKnown struct {
+	sync.Mutex
+
+	config  *dynamic.Configuration
+	updated time.Time
+}
+
+func (l *lastKnown) store(cfg *dynamic.Configuration) {
+	l.Lock()
+	defer l.Unlock()
+
+	l.config, l.updated = cfg, time.Now()
+}
+
+func (l *lastKnown) reset() { l.store(nil) }
+
+// load returns the cached configuration and its age when it is not older than maxAge.
+func (l *lastKnown) load(maxAge time.Duration) (*dynamic.Configuration, time.Duration, bool) {
+	l.Lock()
+	defer l.Unlock()
+
+	if l.config == nil {
+		return nil, 0, false
+	}
+
+	age := time.Since(l.updated)
+	if age > maxAge {
+		l.config = nil
+
+		return nil, age, false
+	}
+
+	return l.config, age, true
+}
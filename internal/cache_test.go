@@ -0,0 +1,37 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/genconf/dynamic"
+)
+
+func TestLastKnown(t *testing.T) {
+	var cache lastKnown
+
+	_, _, ok := cache.load(time.Minute)
+	require.False(t, ok)
+
+	cfg := new(dynamic.Configuration)
+	cache.store(cfg)
+
+	res, _, ok := cache.load(time.Minute)
+	require.True(t, ok)
+	require.Same(t, cfg, res)
+
+	cache.updated = time.Now().Add(-time.Hour)
+	_, age, ok := cache.load(time.Minute)
+	require.False(t, ok)
+	require.Greater(t, age, time.Minute)
+
+	_, age, ok = cache.load(time.Minute)
+	require.False(t, ok)
+	require.Zero(t, age)
+
+	cache.store(cfg)
+	cache.reset()
+	_, _, ok = cache.load(time.Minute)
+	require.False(t, ok)
+}
@@ -0,0 +1,123 @@
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/traefik/genconf/dynamic"
+	"github.com/traefik/genconf/dynamic/types"
+)
+
+// TLSRule selects the certificate resolver and TLS options of the secure routers
+// whose hosts all end with Suffix or all match Regex.
+type TLSRule struct {
+	Suffix       string `json:"suffix"       yaml:"suffix"       toml:"suffix"       mapstructure:"suffix"`
+	Regex        string `json:"regex"        yaml:"regex"        toml:"regex"        mapstructure:"regex"`
+	CertResolver string `json:"certResolver" yaml:"certResolver" toml:"certResolver" mapstructure:"certResolver"`
+	Options      string `json:"options"      yaml:"options"      toml:"options"      mapstructure:"options"`
+}
+
+type tlsRule struct {
+	match pattern
+	tls   dynamic.RouterTLSConfig
+}
+
+func validateWildcards(domains []string) error {
+	for _, domain := range domains {
+		if name, ok := strings.CutPrefix(domain, wildcardPrefix); !ok || name == "" || strings.Contains(name, "*") {
+			return fmt.Errorf("expected *.example.com, got %q", domain)
+		}
+	}
+
+	return nil
+}
+
+// wildcard returns the wildcard domain covering the host. Like a wildcard
+// certificate, it only covers a single label.
+func (e Endpoint) wildcard(host string) (string, bool) {
+	for _, domain := range e.WildcardDomains {
+		label, ok := strings.CutSuffix(strings.ToLower(host), strings.ToLower(strings.TrimPrefix(domain, "*")))
+		if ok && label != "" && !strings.Contains(label, ".") {
+			return strings.ToLower(domain), true
+		}
+	}
+
+	return "", false
+}
+
+// tlsDomains returns the certificate domains of a rule: a domain per wildcard
+// covering some of its hosts, and a single domain holding the other hosts.
+func (e Endpoint) tlsDomains(rule string) []types.Domain {
+	var (
+		out   []types.Domain
+		hosts []string
+	)
+
+	for _, host := range RuleHosts(rule) {
+		if domain, ok := e.wildcard(host); !ok {
+			hosts = append(hosts, host)
+		} else if !slices.ContainsFunc(out, func(item types.Domain) bool { return item.Main == domain }) {
+			out = append(out, types.Domain{Main: domain})
+		}
+	}
+
+	if len(hosts) > 1 {
+		out = append(out, types.Domain{Main: hosts[0], SANs: hosts[1:]})
+	} else if len(hosts) == 1 {
+		out = append(out, types.Domain{Main: hosts[0]})
+	}
+
+	return out
+}
+
+func compileTLSRules(items []TLSRule) ([]tlsRule, error) {
+	out := make([]tlsRule, 0, len(items))
+	for i, item := range items {
+		rule := tlsRule{tls: dynamic.RouterTLSConfig{CertResolver: item.CertResolver, Options: item.Options}}
+		switch {
+		case (item.Suffix == "") == (item.Regex == ""):
+			return nil, fmt.Errorf("#%d rule: expected either suffix or regex", i)
+		case item.Suffix != "":
+			suffix := strings.ToLower(item.Suffix)
+			rule.match = func(host string) bool { return strings.HasSuffix(strings.ToLower(host), suffix) }
+		default:
+			re, err := regexp.Compile(item.Regex)
+			if err != nil {
+				return nil, fmt.Errorf("#%d rule: %w", i, err)
+			}
+
+			rule.match = re.MatchString
+		}
+
+		out = append(out, rule)
+	}
+
+	return out, nil
+}
+
+// tlsConfig returns the TLS config of the secure twin of a router: the first TLS
+// rule matching every host of the rule wins, the global resolver and options are
+// the fallback. Without a fallback unmatched routers get no secure twin.
+func (c *Client) tlsConfig(rule string) *dynamic.RouterTLSConfig {
+	hosts := RuleHosts(rule)
+	for _, item := range c.tlsRules {
+		if len(hosts) > 0 && !slices.ContainsFunc(hosts, func(host string) bool { return !item.match(host) }) {
+			out := item.tls
+
+			return &out
+		}
+	}
+
+	if c.resolver == nil && c.tlsOptions == "" {
+		return nil
+	}
+
+	out := &dynamic.RouterTLSConfig{Options: c.tlsOptions}
+	if c.resolver != nil {
+		out.CertResolver = *c.resolver
+	}
+
+	return out
+}
@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/genconf/dynamic"
+	"github.com/traefik/genconf/dynamic/types"
+)
+
+func TestValidateWildcards(t *testing.T) {
+	require.NoError(t, validateWildcards([]string{"*.example.com", "*.lab.example.com"}))
+	require.Error(t, validateWildcards([]string{"example.com"}))
+	require.Error(t, validateWildcards([]string{"*."}))
+	require.Error(t, validateWildcards([]string{"*.*.example.com"}))
+}
+
+func TestEndpoint_tlsDomains(t *testing.T) {
+	var endpoint Endpoint
+	require.Empty(t, endpoint.tlsDomains("PathPrefix(`/`)"))
+	require.Equal(t, []types.Domain{{Main: "a.example.com", SANs: []string{"b.example.com"}}},
+		endpoint.tlsDomains("Host(`a.example.com`) || Host(`b.example.com`) && PathPrefix(`/`)"))
+
+	endpoint.WildcardDomains = []string{"*.Lab.example.com"}
+	require.Equal(t, []types.Domain{{Main: "*.lab.example.com"}},
+		endpoint.tlsDomains("Host(`a.lab.example.com`) || Host(`B.lab.example.com`)"))
+	require.Equal(t, []types.Domain{{Main: "*.lab.example.com"}, {Main: "b.c.lab.example.com"}},
+		endpoint.tlsDomains("Host(`a.lab.example.com`) || Host(`b.c.lab.example.com`)"))
+	require.Equal(t, []types.Domain{{Main: "lab.example.com"}}, endpoint.tlsDomains("Host(`lab.example.com`)"))
+}
+
+func TestClient_tlsDomains(t *testing.T) {
+	res := &rawData{
+		Routers: map[string]*rawRouter{
+			"app@docker": {Router: dynamic.Router{Service: "backend", Rule: "Host(`app.lab.example.com`)"}},
+		},
+		Services: map[string]*rawService{
+			"backend@docker": {Service: dynamic.Service{LoadBalancer: &dynamic.ServersLoadBalancer{}}},
+		},
+	}
+
+	resolver := "letsencrypt"
+	cli := &Client{endpoint: Endpoint{Host: "worker", WEB: 80}, resolver: &resolver}
+	cfg := cli.prepareResponse(res)
+	require.Equal(t, &dynamic.RouterTLSConfig{CertResolver: resolver}, cfg.HTTP.Routers["app-docker-worker-secure"].TLS)
+	require.Equal(t, []string{"http2https"}, cfg.HTTP.Routers["app-docker-worker"].Middlewares)
+	require.Empty(t, cfg.HTTP.Routers["app-docker-worker-secure"].Middlewares)
+
+	rules, err := compileTLSRules([]TLSRule{{Suffix: ".example.com", Options: "strict@file"}})
+	require.NoError(t, err)
+
+	cli.tlsRules = rules
+	cfg = cli.prepareResponse(res)
+	require.Equal(t, &dynamic.RouterTLSConfig{Options: "strict@file"}, cfg.HTTP.Routers["app-docker-worker-secure"].TLS)
+
+	cli.tlsRules, cli.resolver = nil, nil
+	cfg = cli.prepareResponse(res)
+	require.NotContains(t, cfg.HTTP.Routers, "app-docker-worker-secure")
+	require.Empty(t, cfg.HTTP.Routers["app-docker-worker"].Middlewares)
+	require.Empty(t, cfg.HTTP.Middlewares)
+
+	cli.resolver = &resolver
+	cli.certDomains = true
+	cfg = cli.prepareResponse(res)
+	require.Equal(t, []types.Domain{{Main: "app.lab.example.com"}},
+		cfg.HTTP.Routers["app-docker-worker-secure"].TLS.Domains)
+
+	cli.endpoint.WildcardDomains = []string{"*.lab.example.com"}
+	cfg = cli.prepareResponse(res)
+	require.Equal(t, []types.Domain{{Main: "*.lab.example.com"}}, cfg.HTTP.Routers["app-docker-worker-secure"].TLS.Domains)
+}
+
+func TestCompileTLSRules(t *testing.T) {
+	_, err := compileTLSRules([]TLSRule{{CertResolver: "private"}})
+	require.ErrorContains(t, err, "#0 rule: expected either suffix or regex")
+
+	_, err = compileTLSRules([]TLSRule{{Suffix: ".lan"}, {Suffix: ".lan", Regex: "lan"}})
+	require.ErrorContains(t, err, "#1 rule: expected either suffix or regex")
+
+	_, err = compileTLSRules([]TLSRule{{Regex: "("}})
+	require.ErrorContains(t, err, "#0 rule")
+}
+
+func TestClient_tlsConfig(t *testing.T) {
+	rules, err := compileTLSRules([]TLSRule{
+		{Suffix: ".LAN", CertResolver: "private"},
+		{Regex: `^(bank|pay)\.example\.com$`, CertResolver: "letsencrypt", Options: "strict@file"},
+	})
+	require.NoError(t, err)
+
+	cli := &Client{tlsRules: rules}
+	require.Equal(t, &dynamic.RouterTLSConfig{CertResolver: "private"}, cli.tlsConfig("Host(`nas.lan`)"))
+	require.Equal(t, &dynamic.RouterTLSConfig{CertResolver: "letsencrypt", Options: "strict@file"},
+		cli.tlsConfig("Host(`bank.example.com`) || Host(`pay.example.com`)"))
+	require.Nil(t, cli.tlsConfig("Host(`nas.lan`) || Host(`bank.example.com`)"))
+	require.Nil(t, cli.tlsConfig("PathPrefix(`/`)"))
+
+	cli.tlsOptions = "modern@file"
+	require.Equal(t, &dynamic.RouterTLSConfig{Options: "modern@file"}, cli.tlsConfig("Host(`app.example.com`)"))
+
+	resolver := "letsencrypt"
+	cli.resolver = &resolver
+	require.Equal(t, &dynamic.RouterTLSConfig{CertResolver: resolver, Options: "modern@file"},
+		cli.tlsConfig("PathPrefix(`/`)"))
+	require.Equal(t, &dynamic.RouterTLSConfig{CertResolver: "private"}, cli.tlsConfig("Host(`nas.lan`)"))
+}
@@ -7,9 +7,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log"
+	"net"
 	"net/http"
-	"net/url"
+	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/traefik/genconf/dynamic"
 )
@@ -17,13 +21,33 @@ import (
 type Client struct {
 	*http.Client
 
-	endpoint Endpoint
-	resolver *string
+	endpoint    Endpoint
+	resolver    *string
+	tlsOptions  string
+	tlsRules    []tlsRule
+	redirect    *Redirect
+	maxStale    time.Duration
+	filters     []*routerFilter
+	marker      string
+	copyMW      bool
+	weighted    bool
+	certDomains bool
+	lastGood    lastKnown
+	pending     atomic.Bool
 }
 
-const defaultRawPath = "/api/rawdata"
+const (
+	defaultRawPath = "/api/rawdata"
 
-var ErrEmptyResponse = errors.New("received empty response")
+	// maxErrorBody limits the part of an error response kept in the error message.
+	maxErrorBody = 512
+)
+
+var (
+	ErrEmptyResponse    = errors.New("received empty response")
+	ErrStaleConfig      = errors.New("serving cached config")
+	ErrUnexpectedStatus = errors.New("unexpected response status")
+)
 
 func (c *Client) Endpoint() string {
 	if c == nil {
@@ -33,54 +57,166 @@ func (c *Client) Endpoint() string {
 	return c.endpoint.Host
 }
 
-func (c *Client) httpCall(ctx context.Context) (*dynamic.Configuration, error) {
-	uri := url.URL{
-		Scheme: "http",
-		Path:   defaultRawPath,
-		Host:   fmt.Sprintf("%s:%d", c.endpoint.Host, c.endpoint.API),
-	}
+// Weight returns the share of traffic of the endpoint in aggregated routes.
+func (c *Client) Weight() int { return c.endpoint.Weight }
+
+func (c *Client) httpCall(ctx context.Context) (*rawData, error) {
+	uri := c.endpoint.rawURL()
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri.String(), nil)
 	if err != nil {
 		return nil, fmt.Errorf("could not prepare request for %s: %w", uri.String(), err)
 	}
 
+	var secrets []string
+	if secrets, err = c.endpoint.Auth.apply(req); err != nil {
+		return nil, fmt.Errorf("could not authorize request for %s: %w", uri.String(), err)
+	}
+
 	var res *http.Response
 	if res, err = c.Do(req); err != nil {
 		return nil, fmt.Errorf("could not make request for %s: %w", uri.String(), err)
 	}
 
+	// error pages may still be JSON, decoding them would withdraw every route
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, maxErrorBody))
+
+		return nil, errors.Join(fmt.Errorf(
+			"%w %q for %s: %s",
+			ErrUnexpectedStatus,
+			res.Status,
+			uri.String(),
+			redact(string(body), secrets),
+		), res.Body.Close())
+	}
+
 	buf := new(bytes.Buffer)
 	tee := io.TeeReader(res.Body, buf)
 
-	var result dynamic.Configuration
-	if err = json.NewDecoder(tee).Decode(&result.HTTP); err != nil {
+	var raw rawData
+	if err = json.NewDecoder(tee).Decode(&raw); err != nil {
 		return nil, fmt.Errorf(
 			"could not decode response for %s: %s: %w",
 			uri.String(),
-			buf.String(),
+			redact(buf.String(), secrets),
 			err,
 		)
 	}
 
-	return &result, res.Body.Close()
+	for _, err = range raw.malformed {
+		log.Printf("skip malformed entry(client:%q): %s", c.endpoint.Host, err)
+	}
+
+	return &raw, res.Body.Close()
+}
+
+// serviceKey resolves the rawdata key of the service a router points to.
+// Traefik omits the provider namespace when a router references a service
+// of its own provider, so the router's one is applied in that case.
+func serviceKey(router, service string) string {
+	if service == "" {
+		return router
+	} else if strings.Contains(service, "@") {
+		return service
+	} else if idx := strings.LastIndex(router, "@"); idx >= 0 {
+		return service + router[idx:]
+	}
+
+	return service
+}
+
+// scopedName returns the central name of an entry copied from the endpoint.
+// The provider is kept, so same-named entries of different providers do not collide,
+// and tags (e.g. the entrypoint of an UDP router) tell apart entries derived from one key.
+func (c *Client) scopedName(key string, tags ...string) string {
+	parts := []string{key}
+	if name, provider, ok := strings.Cut(key, "@"); ok {
+		parts = []string{name, provider}
+	}
+
+	return strings.Join(append(append(parts, tags...), c.endpoint.Host), "-")
+}
+
+// enabled reports whether an entry may be translated. Disabled entries are
+// skipped, warnings are only logged because the remote still serves them.
+func (c *Client) enabled(kind, key string, state rawState) bool {
+	switch {
+	case state.disabled():
+		log.Printf("skip %s %q(client:%q): disabled: %s", kind, key, c.endpoint.Host, state.errors())
+
+		return false
+	case state.Status == statusWarning || len(state.Err) > 0:
+		log.Printf("%s %q(client:%q) reports errors: %s", kind, key, c.endpoint.Host, state.errors())
+	}
+
+	return true
 }
 
-func (c *Client) prepareResponse(res *dynamic.Configuration) *dynamic.Configuration {
+// parsable reports whether the rule of a router can be exported. Generated routers
+// carry no ruleSyntax, so the central instance parses every rule with its default one.
+func (c *Client) parsable(key string, item *rawRouter) bool {
+	if item.RuleSyntax == ruleSyntaxV2 {
+		log.Printf("skip router %q(client:%q): rule syntax %q is not supported", key, c.endpoint.Host, item.RuleSyntax)
+
+		return false
+	}
+
+	return true
+}
+
+// exported applies the global and endpoint filters to a router.
+func (c *Client) exported(kind string, item candidate) bool {
+	for _, filter := range c.filters {
+		if ok, reason := filter.allow(item); !ok {
+			log.Printf("skip %s %q(client:%q): %s", kind, item.key, c.endpoint.Host, reason)
+
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c *Client) prepareResponse(res *rawData) *dynamic.Configuration {
 	var output dynamic.Configuration
-	for key, item := range res.HTTP.Routers {
-		if strings.HasSuffix(key, "@internal") {
+	for key, item := range res.Routers {
+		if strings.HasSuffix(key, "@internal") || !c.enabled("router", key, item.rawState) || !c.parsable(key, item) {
 			continue
 		}
 
-		name := strings.Split(key, "@")[0]
-		name = fmt.Sprintf("%s-%s", name, c.endpoint.Host)
+		if !c.exported("router", candidate{key: key, entryPoints: item.EntryPoints, hosts: RuleHosts(item.Rule)}) ||
+			!c.optedIn("router", key, &item.Middlewares) || !c.allowed("router", key, item.Rule) {
+			continue
+		}
+
+		entryPoints, ok := c.entryPoints("router", key, item.EntryPoints)
+		if !ok {
+			continue
+		}
 
-		service, ok := res.HTTP.Services[key]
+		name := c.scopedName(key)
+
+		ref := serviceKey(key, item.Service)
+		service, ok := res.Services[ref]
 		if !ok {
+			log.Printf("skip router %q(client:%q): service %q not found", key, c.endpoint.Host, ref)
+
+			continue
+		} else if !c.enabled("service", ref, service.rawState) {
+			continue
+		} else if service.LoadBalancer == nil {
+			log.Printf("skip router %q(client:%q): service %q has no loadBalancer", key, c.endpoint.Host, ref)
+
 			continue
 		}
 
+		uniq := c.scopedName(ref)
+		if c.secureUpstream(item) {
+			// the transport verifies the hosts of the router, so the service is not shared
+			uniq = c.scopedName(key, "tls")
+		}
+
 		if output.HTTP == nil {
 			output.HTTP = &dynamic.HTTPConfiguration{
 				Routers:     make(map[string]*dynamic.Router),
@@ -89,56 +225,292 @@ func (c *Client) prepareResponse(res *dynamic.Configuration) *dynamic.Configurat
 			}
 		}
 
+		var middlewares []string
+		if c.copyMW {
+			middlewares = c.copyMiddlewares(res, output.HTTP, key, item.Middlewares)
+		}
+
 		output.HTTP.Routers[name] = &dynamic.Router{
-			Service: name,
-			Rule:    item.Rule,
+			EntryPoints: entryPoints,
+			Middlewares: middlewares,
+			Service:     uniq,
+			Rule:        item.Rule,
+			Priority:    c.endpoint.priority(item.Priority, item.Rule),
 		}
 
-		var servers []dynamic.Server
-		for range service.LoadBalancer.Servers {
-			servers = append(servers, dynamic.Server{URL: (&url.URL{
-				Scheme: "http",
-				Host:   fmt.Sprintf("%s:%d", c.endpoint.Host, c.endpoint.WEB),
-			}).String()})
+		c.prepareService(output.HTTP, uniq, ref, service, item)
+		c.prepareSecure(output.HTTP, name)
+	}
+
+	c.prepareTCP(res, &output)
+	c.prepareUDP(res, &output)
+
+	return &output
+}
+
+// prepareSecure adds the TLS twin of a generated router and redirects the plain one to it,
+// or replaces the plain one in secure-only mode.
+func (c *Client) prepareSecure(output *dynamic.HTTPConfiguration, name string) {
+	router := output.Routers[name]
+
+	secure := *router
+	if secure.TLS = c.tlsConfig(router.Rule); secure.TLS == nil {
+		return
+	}
+
+	if c.certDomains {
+		secure.TLS.Domains = c.endpoint.tlsDomains(router.Rule)
+	}
+
+	if entryPoints := c.redirect.entryPoints(); len(entryPoints) > 0 {
+		secure.EntryPoints = entryPoints
+	}
+
+	output.Routers[name+"-secure"] = &secure
+
+	if c.redirect.secureOnly() {
+		delete(output.Routers, name)
+
+		return
+	}
+
+	if ref, middleware := c.redirect.middleware(); middleware != nil {
+		// copied middlewares (e.g. basicAuth) run on the secure twin only, never over plain HTTP
+		router.Middlewares = []string{ref}
+		output.Middlewares[ref] = middleware
+	}
+}
+
+// prepareService generates a service with a single server pointing to the
+// endpoint: the remote instance balances between its own servers.
+func (c *Client) prepareService(
+	output *dynamic.HTTPConfiguration,
+	uniq, ref string,
+	service *rawService,
+	router *rawRouter,
+) {
+	balancer := &dynamic.Service{LoadBalancer: c.balancer(output, uniq, router)}
+
+	if !c.weighted {
+		output.Services[uniq] = balancer
+
+		return
+	}
+
+	weight := service.upServers()
+	if weight == 0 {
+		log.Printf("service %q(client:%q) has no UP servers, use weight 1", ref, c.endpoint.Host)
+
+		weight = 1
+	}
+
+	output.Services[uniq+"-lb"] = balancer
+	output.Services[uniq] = &dynamic.Service{
+		Weighted: &dynamic.WeightedRoundRobin{
+			Services: []dynamic.WRRService{{Name: uniq + "-lb", Weight: &weight}},
+		},
+	}
+}
+
+func (c *Client) prepareTCP(res *rawData, output *dynamic.Configuration) {
+	if c.endpoint.TCP <= 0 {
+		return
+	}
+
+	for key, item := range res.TCPRouters {
+		if strings.HasSuffix(key, "@internal") || !c.enabled("tcp router", key, item.rawState) {
+			continue
 		}
 
-		output.HTTP.Services[name] = &dynamic.Service{
-			LoadBalancer: &dynamic.ServersLoadBalancer{Servers: servers},
+		if !c.exported("tcp router", candidate{key: key, entryPoints: item.EntryPoints, hosts: RuleHosts(item.Rule)}) ||
+			!c.optedIn("tcp router", key, &item.Middlewares) || !c.allowed("tcp router", key, item.Rule) {
+			continue
 		}
 
-		if c.resolver != nil {
-			output.HTTP.Routers[name].Middlewares = append(
-				output.HTTP.Routers[name].Middlewares,
-				"http2https",
+		if !strings.Contains(item.Rule, "HostSNI") {
+			log.Printf(
+				"skip tcp router %q(client:%q): rule %q has no HostSNI matcher",
+				key,
+				c.endpoint.Host,
+				item.Rule,
 			)
 
-			output.HTTP.Routers[name+"-secure"] = &dynamic.Router{
-				Service: name,
-				Rule:    item.Rule,
-				TLS:     &dynamic.RouterTLSConfig{CertResolver: *c.resolver},
-			}
+			continue
+		}
+
+		entryPoints, ok := c.tcpEntryPoints(key, item.EntryPoints)
+		if !ok {
+			continue
+		}
+
+		ref := serviceKey(key, item.Service)
+		if service, ok := res.TCPServices[ref]; !ok {
+			log.Printf("skip tcp router %q(client:%q): service %q not found", key, c.endpoint.Host, ref)
 
-			output.HTTP.Middlewares["http2https"] = &dynamic.Middleware{
-				RedirectScheme: &dynamic.RedirectScheme{Scheme: "https", Permanent: true},
+			continue
+		} else if !c.enabled("tcp service", ref, service.rawState) {
+			continue
+		}
+
+		name := c.scopedName(key)
+		uniq := c.scopedName(ref)
+
+		if output.TCP == nil {
+			output.TCP = &dynamic.TCPConfiguration{
+				Routers:  make(map[string]*dynamic.TCPRouter),
+				Services: make(map[string]*dynamic.TCPService),
 			}
 		}
+
+		router := &dynamic.TCPRouter{
+			EntryPoints: entryPoints,
+			Service:     uniq,
+			Rule:        item.Rule,
+			Priority:    c.endpoint.priority(item.Priority, item.Rule),
+		}
+
+		// TLS is terminated (or passed through) by the remote instance,
+		// so the central one only has to route by SNI.
+		if item.TLS != nil {
+			router.TLS = &dynamic.RouterTCPTLSConfig{Passthrough: true}
+		}
+
+		output.TCP.Routers[name] = router
+		output.TCP.Services[uniq] = &dynamic.TCPService{
+			LoadBalancer: &dynamic.TCPServersLoadBalancer{
+				Servers: []dynamic.TCPServer{{
+					Address: net.JoinHostPort(c.endpoint.Host, strconv.Itoa(c.endpoint.TCP)),
+				}},
+			},
+		}
 	}
+}
 
-	return &output
+func (c *Client) prepareUDP(res *rawData, output *dynamic.Configuration) {
+	if len(c.endpoint.UDP) == 0 {
+		return
+	}
+
+	for key, item := range res.UDPRouters {
+		if strings.HasSuffix(key, "@internal") || !c.enabled("udp router", key, item.rawState) {
+			continue
+		}
+
+		if !c.exported("udp router", candidate{key: key, entryPoints: item.EntryPoints}) {
+			continue
+		}
+
+		ref := serviceKey(key, item.Service)
+		if service, ok := res.UDPServices[ref]; !ok {
+			log.Printf("skip udp router %q(client:%q): service %q not found", key, c.endpoint.Host, ref)
+
+			continue
+		} else if !c.enabled("udp service", ref, service.rawState) {
+			continue
+		}
+
+		for _, entrypoint := range item.EntryPoints {
+			port, ok := c.endpoint.UDP[entrypoint]
+			if !ok {
+				log.Printf(
+					"skip udp router %q(client:%q): entrypoint %q has no udpPorts mapping",
+					key,
+					c.endpoint.Host,
+					entrypoint,
+				)
+
+				continue
+			}
+
+			central := entrypoint
+			if c.endpoint.mapsEntryPoints() {
+				if central, ok = c.endpoint.centralEntryPoint(entrypoint); !ok {
+					log.Printf("skip udp router %q(client:%q): entrypoint %q is not mapped", key, c.endpoint.Host, entrypoint)
+
+					continue
+				}
+			}
+
+			if output.UDP == nil {
+				output.UDP = &dynamic.UDPConfiguration{
+					Routers:  make(map[string]*dynamic.UDPRouter),
+					Services: make(map[string]*dynamic.UDPService),
+				}
+			}
+
+			uniq := c.scopedName(key, entrypoint)
+			output.UDP.Routers[uniq] = &dynamic.UDPRouter{
+				EntryPoints: []string{central},
+				Service:     uniq,
+			}
+
+			output.UDP.Services[uniq] = &dynamic.UDPService{
+				LoadBalancer: &dynamic.UDPServersLoadBalancer{
+					Servers: []dynamic.UDPServer{{
+						Address: net.JoinHostPort(c.endpoint.Host, strconv.Itoa(port)),
+					}},
+				},
+			}
+		}
+	}
 }
 
 func (c *Client) FetchRaw(ctx context.Context, out chan<- *dynamic.Configuration) error {
 	if res, err := c.httpCall(ctx); err != nil {
+		if cfg, ok := c.fallback(err); ok {
+			out <- cfg
+
+			return fmt.Errorf("%w(client:%q): %w", ErrStaleConfig, c.endpoint.Host, err)
+		}
+
 		out <- nil
 
 		return err
-	} else if len(res.HTTP.Routers) > 0 && len(res.HTTP.Services) > 0 {
-		out <- c.prepareResponse(res)
+	} else if len(res.Routers) > 0 && len(res.Services) > 0 ||
+		len(res.TCPRouters) > 0 ||
+		len(res.UDPRouters) > 0 {
+		if c.pending.CompareAndSwap(true, false) {
+			log.Printf("endpoint %q is online", c.endpoint.Host)
+		}
+
+		cfg := c.prepareResponse(res)
+		c.lastGood.store(cfg)
+
+		out <- cfg
 
 		return nil
 	}
 
+	c.lastGood.reset()
+
 	out <- nil
 
 	return fmt.Errorf("%w (1client:%q)", ErrEmptyResponse, c.endpoint.Host)
 }
+
+// fallback returns the last-known-good configuration of the endpoint
+// while it is not older than the configured maximum staleness.
+func (c *Client) fallback(cause error) (*dynamic.Configuration, bool) {
+	if c.maxStale <= 0 {
+		return nil, false
+	}
+
+	cfg, age, ok := c.lastGood.load(c.maxStale)
+	if !ok {
+		if age > 0 {
+			log.Printf("drop cached config(client:%q): stale for %s", c.endpoint.Host, age.Round(time.Second))
+		}
+
+		return nil, false
+	}
+
+	log.Printf(
+		"use cached config(client:%q, age:%s, maxStaleness:%s): %s",
+		c.endpoint.Host,
+		age.Round(time.Millisecond),
+		c.maxStale,
+		cause,
+	)
+
+	return cfg, true
+}
@@ -2,12 +2,12 @@ package internal
 
 import (
 	"context"
-	"io"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -89,12 +89,17 @@ func TestClient(t *testing.T) {
 	require.NoError(t, err)
 
 	require.Equal(t, cli[0].Endpoint(), addr.IP.String())
+	require.False(t, cli[0].pending.Load())
+
+	cli[0].pending.Store(true)
 
 	out := make(chan *dynamic.Configuration, 1)
 	if err = cli[0].FetchRaw(t.Context(), out); err != nil {
 		t.Fatal(err)
 	}
 
+	require.False(t, cli[0].pending.Load(), "endpoint is online after the first successful fetch")
+
 	var result *dynamic.Configuration
 	select {
 	case <-ctx.Done():
@@ -104,19 +109,21 @@ func TestClient(t *testing.T) {
 		require.Equal(t, &dynamic.Configuration{
 			HTTP: &dynamic.HTTPConfiguration{
 				Routers: map[string]*dynamic.Router{
-					"whoami-" + addr.IP.String(): {
+					"whoami-docker-" + addr.IP.String(): {
 						Middlewares: []string{"http2https"},
-						Service:     "whoami-" + addr.IP.String(),
+						Service:     "whoami-docker-" + addr.IP.String(),
 						Rule:        "Host(`whoami.example.com`)",
+						Priority:    21,
 					},
-					"whoami-" + addr.IP.String() + "-secure": {
-						Service: "whoami-" + addr.IP.String(),
-						Rule:    "Host(`whoami.example.com`)",
-						TLS:     &dynamic.RouterTLSConfig{CertResolver: resolver},
+					"whoami-docker-" + addr.IP.String() + "-secure": {
+						Service:  "whoami-docker-" + addr.IP.String(),
+						Rule:     "Host(`whoami.example.com`)",
+						Priority: 21,
+						TLS:      &dynamic.RouterTLSConfig{CertResolver: resolver},
 					},
 				},
 				Services: map[string]*dynamic.Service{
-					"whoami-" + addr.IP.String(): {
+					"whoami-docker-" + addr.IP.String(): {
 						LoadBalancer: &dynamic.ServersLoadBalancer{
 							Servers: []dynamic.Server{{URL: (&url.URL{
 								Scheme: "http",
@@ -246,7 +253,7 @@ func TestClient_failure(t *testing.T) {
 	require.NoError(t, err)
 
 	out := make(chan *dynamic.Configuration, 1)
-	require.ErrorIs(t, cli[0].FetchRaw(t.Context(), out), io.EOF)
+	require.ErrorIs(t, cli[0].FetchRaw(t.Context(), out), ErrUnexpectedStatus)
 
 	select {
 	case <-ctx.Done():
@@ -255,3 +262,490 @@ func TestClient_failure(t *testing.T) {
 		require.Empty(t, msg)
 	}
 }
+
+func TestClient_tcp(t *testing.T) {
+	data, err := os.ReadFile("../fixtures/tcp-api-rawdata.json")
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+
+		assert.NoError(t, catchError(w.Write(data)))
+	}))
+
+	addr, ok := srv.Listener.Addr().(*net.TCPAddr)
+	require.True(t, ok)
+
+	ctx, cancel := context.WithTimeout(t.Context(), time.Millisecond*100)
+	defer cancel()
+
+	cfg := Config{
+		ConnTimeout:  defaultTestConnTimeout,
+		PollInterval: defaultTestPollInterval,
+		Endpoints: []Endpoint{{
+			Host: addr.IP.String(),
+			API:  addr.Port,
+			WEB:  addr.Port,
+			TCP:  5432,
+		}},
+	}
+
+	cli, err := cfg.PrepareClients(ctx)
+	require.NoError(t, err)
+
+	out := make(chan *dynamic.Configuration, 1)
+	require.NoError(t, cli[0].FetchRaw(t.Context(), out))
+
+	result := <-out
+	require.NotNil(t, result.HTTP)
+	require.Equal(t, &dynamic.TCPConfiguration{
+		Routers: map[string]*dynamic.TCPRouter{
+			"postgres-docker-" + addr.IP.String(): {
+				EntryPoints: []string{"tcp"},
+				Service:     "postgres-docker-" + addr.IP.String(),
+				Rule:        "HostSNI(`db.example.com`)",
+				TLS:         &dynamic.RouterTCPTLSConfig{Passthrough: true},
+			},
+			"mqtt-docker-" + addr.IP.String(): {
+				EntryPoints: []string{"tcp"},
+				Service:     "mqtt-docker-" + addr.IP.String(),
+				Rule:        "HostSNI(`*`)",
+			},
+		},
+		Services: map[string]*dynamic.TCPService{
+			"postgres-docker-" + addr.IP.String(): {
+				LoadBalancer: &dynamic.TCPServersLoadBalancer{
+					Servers: []dynamic.TCPServer{{Address: addr.IP.String() + ":5432"}},
+				},
+			},
+			"mqtt-docker-" + addr.IP.String(): {
+				LoadBalancer: &dynamic.TCPServersLoadBalancer{
+					Servers: []dynamic.TCPServer{{Address: addr.IP.String() + ":5432"}},
+				},
+			},
+		},
+	}, result.TCP)
+
+	cli[0].endpoint.TCP = 0
+	require.NoError(t, cli[0].FetchRaw(t.Context(), out))
+	require.Nil(t, (<-out).TCP)
+}
+
+func TestClient_udp(t *testing.T) {
+	data, err := os.ReadFile("../fixtures/udp-api-rawdata.json")
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+
+		assert.NoError(t, catchError(w.Write(data)))
+	}))
+
+	addr, ok := srv.Listener.Addr().(*net.TCPAddr)
+	require.True(t, ok)
+
+	ctx, cancel := context.WithTimeout(t.Context(), time.Millisecond*100)
+	defer cancel()
+
+	cfg := Config{
+		ConnTimeout:  defaultTestConnTimeout,
+		PollInterval: defaultTestPollInterval,
+		Endpoints: []Endpoint{{
+			Host: addr.IP.String(),
+			API:  addr.Port,
+			WEB:  addr.Port,
+			UDP:  map[string]int{"dns": 53, "wireguard": 51820},
+		}},
+	}
+
+	cli, err := cfg.PrepareClients(ctx)
+	require.NoError(t, err)
+
+	out := make(chan *dynamic.Configuration, 1)
+	require.NoError(t, cli[0].FetchRaw(t.Context(), out))
+
+	result := <-out
+	require.Nil(t, result.HTTP)
+	require.Equal(t, &dynamic.UDPConfiguration{
+		Routers: map[string]*dynamic.UDPRouter{
+			"dns-docker-dns-" + addr.IP.String(): {
+				EntryPoints: []string{"dns"},
+				Service:     "dns-docker-dns-" + addr.IP.String(),
+			},
+			"wireguard-docker-wireguard-" + addr.IP.String(): {
+				EntryPoints: []string{"wireguard"},
+				Service:     "wireguard-docker-wireguard-" + addr.IP.String(),
+			},
+		},
+		Services: map[string]*dynamic.UDPService{
+			"dns-docker-dns-" + addr.IP.String(): {
+				LoadBalancer: &dynamic.UDPServersLoadBalancer{
+					Servers: []dynamic.UDPServer{{Address: addr.IP.String() + ":53"}},
+				},
+			},
+			"wireguard-docker-wireguard-" + addr.IP.String(): {
+				LoadBalancer: &dynamic.UDPServersLoadBalancer{
+					Servers: []dynamic.UDPServer{{Address: addr.IP.String() + ":51820"}},
+				},
+			},
+		},
+	}, result.UDP)
+
+	cli[0].endpoint.EntryPoints = map[string]string{"dns": "dns-internal"}
+	require.NoError(t, cli[0].FetchRaw(t.Context(), out))
+
+	result = <-out
+	require.Len(t, result.UDP.Routers, 1)
+	require.Equal(t, []string{"dns-internal"}, result.UDP.Routers["dns-docker-dns-"+addr.IP.String()].EntryPoints)
+}
+
+func TestServiceKey(t *testing.T) {
+	require.Equal(t, "backend@docker", serviceKey("app@docker", "backend"))
+	require.Equal(t, "files@file", serviceKey("app@docker", "files@file"))
+	require.Equal(t, "app@docker", serviceKey("app@docker", ""))
+	require.Equal(t, "backend", serviceKey("app", "backend"))
+}
+
+const sharedServiceResponse = `{
+	"routers": {
+		"app@docker": {"service": "backend", "rule": "Host(` + "`app.example.com`" + `)"},
+		"admin@docker": {"service": "backend", "rule": "Host(` + "`admin.example.com`" + `)"},
+		"static@docker": {"service": "files@file", "rule": "Host(` + "`static.example.com`" + `)"},
+		"missing@docker": {"service": "nope", "rule": "Host(` + "`missing.example.com`" + `)"}
+	},
+	"services": {
+		"backend@docker": {"loadBalancer": {"servers": [{"url": "http://10.0.0.1:80"}]}},
+		"files@file": {"loadBalancer": {"servers": [{"url": "http://10.0.0.2:80"}]}}
+	}
+}`
+
+func TestClient_sharedService(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+
+		assert.NoError(t, catchError(w.Write([]byte(sharedServiceResponse))))
+	}))
+
+	addr, ok := srv.Listener.Addr().(*net.TCPAddr)
+	require.True(t, ok)
+
+	ctx, cancel := context.WithTimeout(t.Context(), time.Millisecond*100)
+	defer cancel()
+
+	cfg := Config{
+		ConnTimeout:  defaultTestConnTimeout,
+		PollInterval: defaultTestPollInterval,
+		Endpoints: []Endpoint{{
+			Host: addr.IP.String(),
+			API:  addr.Port,
+			WEB:  addr.Port,
+		}},
+	}
+
+	cli, err := cfg.PrepareClients(ctx)
+	require.NoError(t, err)
+
+	out := make(chan *dynamic.Configuration, 1)
+	require.NoError(t, cli[0].FetchRaw(t.Context(), out))
+
+	result := <-out
+	require.NotNil(t, result.HTTP)
+
+	host := addr.IP.String()
+	require.Len(t, result.HTTP.Routers, 3)
+	require.NotContains(t, result.HTTP.Routers, "missing-docker-"+host)
+	require.Equal(t, "backend-docker-"+host, result.HTTP.Routers["app-docker-"+host].Service)
+	require.Equal(t, "backend-docker-"+host, result.HTTP.Routers["admin-docker-"+host].Service)
+	require.Equal(t, "files-file-"+host, result.HTTP.Routers["static-docker-"+host].Service)
+
+	require.Len(t, result.HTTP.Services, 2)
+	require.Contains(t, result.HTTP.Services, "backend-docker-"+host)
+	require.Contains(t, result.HTTP.Services, "files-file-"+host)
+}
+
+func TestClient_malformed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+
+		assert.NoError(t, catchError(w.Write([]byte(malformedResponse))))
+	}))
+
+	addr, ok := srv.Listener.Addr().(*net.TCPAddr)
+	require.True(t, ok)
+
+	ctx, cancel := context.WithTimeout(t.Context(), time.Millisecond*100)
+	defer cancel()
+
+	cfg := Config{
+		ConnTimeout:  defaultTestConnTimeout,
+		PollInterval: defaultTestPollInterval,
+		Endpoints: []Endpoint{{
+			Host: addr.IP.String(),
+			API:  addr.Port,
+			WEB:  addr.Port,
+		}},
+	}
+
+	cli, err := cfg.PrepareClients(ctx)
+	require.NoError(t, err)
+
+	out := make(chan *dynamic.Configuration, 1)
+	require.NoError(t, cli[0].FetchRaw(t.Context(), out))
+
+	result := <-out
+	require.NotNil(t, result.HTTP)
+	require.Len(t, result.HTTP.Routers, 1)
+	require.Contains(t, result.HTTP.Routers, "valid-docker-"+addr.IP.String())
+}
+
+func TestClient_lastKnownGood(t *testing.T) {
+	data, err := os.ReadFile("../fixtures/jaeger-api-rawdata.json")
+	require.NoError(t, err)
+
+	var status atomic.Int32
+	status.Store(http.StatusOK)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		code := int(status.Load())
+		w.WriteHeader(code)
+
+		switch code {
+		case http.StatusOK:
+			assert.NoError(t, catchError(w.Write(data)))
+		case http.StatusAccepted:
+			assert.NoError(t, catchError(w.Write([]byte(`{}`))))
+		case http.StatusUnauthorized:
+			assert.NoError(t, catchError(w.Write([]byte(`{"message":"unauthorized"}`))))
+		}
+	}))
+
+	addr, ok := srv.Listener.Addr().(*net.TCPAddr)
+	require.True(t, ok)
+
+	ctx, cancel := context.WithTimeout(t.Context(), time.Millisecond*100)
+	defer cancel()
+
+	cfg := Config{
+		ConnTimeout:  defaultTestConnTimeout,
+		PollInterval: defaultTestPollInterval,
+		MaxStaleness: time.Minute,
+		Endpoints: []Endpoint{{
+			Host: addr.IP.String(),
+			API:  addr.Port,
+			WEB:  addr.Port,
+		}},
+	}
+
+	cli, err := cfg.PrepareClients(ctx)
+	require.NoError(t, err)
+
+	out := make(chan *dynamic.Configuration, 1)
+	require.NoError(t, cli[0].FetchRaw(t.Context(), out))
+	expect := <-out
+	require.NotNil(t, expect)
+
+	status.Store(http.StatusBadGateway)
+	require.ErrorIs(t, cli[0].FetchRaw(t.Context(), out), ErrStaleConfig)
+	require.Same(t, expect, <-out)
+
+	// an error page decoding as JSON must not withdraw the cached routes
+	status.Store(http.StatusUnauthorized)
+	err = cli[0].FetchRaw(t.Context(), out)
+	require.ErrorIs(t, err, ErrStaleConfig)
+	require.ErrorIs(t, err, ErrUnexpectedStatus)
+	require.ErrorContains(t, err, "unauthorized")
+	require.Same(t, expect, <-out)
+
+	cli[0].lastGood.updated = time.Now().Add(-time.Hour)
+	require.ErrorIs(t, cli[0].FetchRaw(t.Context(), out), ErrUnexpectedStatus)
+	require.Nil(t, <-out)
+
+	status.Store(http.StatusOK)
+	require.NoError(t, cli[0].FetchRaw(t.Context(), out))
+	require.NotNil(t, <-out)
+
+	status.Store(http.StatusAccepted)
+	require.ErrorIs(t, cli[0].FetchRaw(t.Context(), out), ErrEmptyResponse)
+	require.Nil(t, <-out)
+
+	status.Store(http.StatusBadGateway)
+	require.ErrorIs(t, cli[0].FetchRaw(t.Context(), out), ErrUnexpectedStatus)
+	require.Nil(t, <-out)
+}
+
+func TestClient_filter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+
+		assert.NoError(t, catchError(w.Write([]byte(sharedServiceResponse))))
+	}))
+
+	addr, ok := srv.Listener.Addr().(*net.TCPAddr)
+	require.True(t, ok)
+
+	cfg := Config{
+		ConnTimeout:  defaultTestConnTimeout,
+		PollInterval: defaultTestPollInterval,
+		Filter:       &Filter{Exclude: &FilterRules{Hosts: []string{"admin.*"}}},
+		Endpoints: []Endpoint{{
+			Host:   addr.IP.String(),
+			API:    addr.Port,
+			WEB:    addr.Port,
+			Filter: &Filter{Include: &FilterRules{Providers: []string{"docker"}}},
+		}},
+	}
+
+	require.NoError(t, cfg.Validate())
+
+	cli, err := cfg.PrepareClients(t.Context())
+	require.NoError(t, err)
+	require.Len(t, cli[0].filters, 2)
+
+	out := make(chan *dynamic.Configuration, 1)
+	require.NoError(t, cli[0].FetchRaw(t.Context(), out))
+
+	result := <-out
+	require.NotNil(t, result.HTTP)
+	require.Len(t, result.HTTP.Routers, 2)
+	require.Contains(t, result.HTTP.Routers, "app-docker-"+addr.IP.String())
+	require.Contains(t, result.HTTP.Routers, "static-docker-"+addr.IP.String())
+}
+
+func TestClient_prepareService(t *testing.T) {
+	res := &rawData{
+		Routers: map[string]*rawRouter{
+			"app@docker": {Router: dynamic.Router{Service: "backend", Rule: "Host(`app.example.com`)"}},
+		},
+		Services: map[string]*rawService{
+			"backend@docker": {
+				Service: dynamic.Service{LoadBalancer: &dynamic.ServersLoadBalancer{
+					Servers: []dynamic.Server{
+						{URL: "http://10.0.0.1:80"},
+						{URL: "http://10.0.0.2:80"},
+						{URL: "http://10.0.0.3:80"},
+					},
+				}},
+				ServerStatus: map[string]string{
+					"http://10.0.0.1:80": "UP",
+					"http://10.0.0.2:80": "UP",
+					"http://10.0.0.3:80": "DOWN",
+				},
+			},
+		},
+	}
+
+	balancer := &dynamic.Service{LoadBalancer: &dynamic.ServersLoadBalancer{
+		Servers: []dynamic.Server{{URL: "http://worker:80"}},
+	}}
+
+	cli := &Client{endpoint: Endpoint{Host: "worker", WEB: 80}}
+	cfg := cli.prepareResponse(res)
+	require.Equal(t, map[string]*dynamic.Service{"backend-docker-worker": balancer}, cfg.HTTP.Services)
+
+	weight := 2
+	cli.weighted = true
+	cfg = cli.prepareResponse(res)
+	require.Equal(t, map[string]*dynamic.Service{
+		"backend-docker-worker-lb": balancer,
+		"backend-docker-worker": {Weighted: &dynamic.WeightedRoundRobin{
+			Services: []dynamic.WRRService{{Name: "backend-docker-worker-lb", Weight: &weight}},
+		}},
+	}, cfg.HTTP.Services)
+	require.Equal(t, "backend-docker-worker", cfg.HTTP.Routers["app-docker-worker"].Service)
+
+	weight = 1
+	res.Services["backend@docker"].ServerStatus = map[string]string{"http://10.0.0.1:80": "DOWN"}
+	cfg = cli.prepareResponse(res)
+	require.Equal(t, &weight, cfg.HTTP.Services["backend-docker-worker"].Weighted.Services[0].Weight)
+}
+
+func TestClient_serviceProviders(t *testing.T) {
+	res := &rawData{
+		Routers: map[string]*rawRouter{
+			"app@docker": {Router: dynamic.Router{Service: "backend", Rule: "Host(`app.example.com`)"}},
+			"api@file":   {Router: dynamic.Router{Service: "backend", Rule: "Host(`api.example.com`)"}},
+		},
+		Services: map[string]*rawService{
+			"backend@docker": {
+				Service:      dynamic.Service{LoadBalancer: &dynamic.ServersLoadBalancer{}},
+				ServerStatus: map[string]string{"http://10.0.0.1:80": "UP", "http://10.0.0.2:80": "UP"},
+			},
+			"backend@file": {
+				Service:      dynamic.Service{LoadBalancer: &dynamic.ServersLoadBalancer{}},
+				ServerStatus: map[string]string{"http://10.0.0.3:80": "UP"},
+			},
+		},
+	}
+
+	cli := &Client{endpoint: Endpoint{Host: "worker", WEB: 80}, weighted: true}
+	cfg := cli.prepareResponse(res)
+	require.Equal(t, "backend-docker-worker", cfg.HTTP.Routers["app-docker-worker"].Service)
+	require.Equal(t, "backend-file-worker", cfg.HTTP.Routers["api-file-worker"].Service)
+
+	two, one := 2, 1
+	require.Equal(t, &two, cfg.HTTP.Services["backend-docker-worker"].Weighted.Services[0].Weight)
+	require.Equal(t, &one, cfg.HTTP.Services["backend-file-worker"].Weighted.Services[0].Weight)
+}
+
+func TestClient_routerProviders(t *testing.T) {
+	res := &rawData{
+		Routers: map[string]*rawRouter{
+			"app@docker": {Router: dynamic.Router{Service: "backend", Rule: "Host(`app.example.com`)"}},
+			"app@file": {Router: dynamic.Router{
+				Service: "backend",
+				Rule:    "Host(`files.example.com`)",
+				TLS:     &dynamic.RouterTLSConfig{},
+			}},
+		},
+		Services: map[string]*rawService{
+			"backend@docker": {Service: dynamic.Service{LoadBalancer: &dynamic.ServersLoadBalancer{}}},
+			"backend@file":   {Service: dynamic.Service{LoadBalancer: &dynamic.ServersLoadBalancer{}}},
+		},
+		UDPRouters: map[string]*rawUDPRouter{
+			"dns@docker": {UDPRouter: dynamic.UDPRouter{EntryPoints: []string{"dns"}, Service: "dns"}},
+			"dns@file":   {UDPRouter: dynamic.UDPRouter{EntryPoints: []string{"dns"}, Service: "dns"}},
+		},
+		UDPServices: map[string]*rawUDPService{
+			"dns@docker": {},
+			"dns@file":   {},
+		},
+	}
+
+	cli := &Client{endpoint: Endpoint{Host: "worker", WEB: 80, WebSecure: 443, UDP: map[string]int{"dns": 53}}}
+	cfg := cli.prepareResponse(res)
+	require.Len(t, cfg.HTTP.Routers, 2)
+	require.Equal(t, "Host(`app.example.com`)", cfg.HTTP.Routers["app-docker-worker"].Rule)
+	require.Equal(t, "backend-docker-worker", cfg.HTTP.Routers["app-docker-worker"].Service)
+	require.Equal(t, "Host(`files.example.com`)", cfg.HTTP.Routers["app-file-worker"].Rule)
+	require.Equal(t, "app-file-tls-worker", cfg.HTTP.Routers["app-file-worker"].Service)
+	require.Contains(t, cfg.HTTP.ServersTransports, "app-file-tls-worker")
+
+	require.Len(t, cfg.UDP.Routers, 2)
+	require.Contains(t, cfg.UDP.Routers, "dns-docker-dns-worker")
+	require.Contains(t, cfg.UDP.Routers, "dns-file-dns-worker")
+}
+
+func TestClient_ruleSyntax(t *testing.T) {
+	res := &rawData{
+		Routers: map[string]*rawRouter{
+			"app@docker": {Router: dynamic.Router{Service: "backend", Rule: "Host(`app.example.com`)"}},
+			"v3@docker": {
+				Router:     dynamic.Router{Service: "backend", Rule: "Host(`v3.example.com`)"},
+				RuleSyntax: "v3",
+			},
+			"legacy@docker": {
+				Router:     dynamic.Router{Service: "backend", Rule: "Host(`a.example.com`,`b.example.com`)"},
+				RuleSyntax: "v2",
+			},
+		},
+		Services: map[string]*rawService{
+			"backend@docker": {Service: dynamic.Service{LoadBalancer: &dynamic.ServersLoadBalancer{}}},
+		},
+	}
+
+	cli := &Client{endpoint: Endpoint{Host: "worker", WEB: 80}}
+	cfg := cli.prepareResponse(res)
+	require.Len(t, cfg.HTTP.Routers, 2)
+	require.Contains(t, cfg.HTTP.Routers, "app-docker-worker")
+	require.Contains(t, cfg.HTTP.Routers, "v3-docker-worker")
+}
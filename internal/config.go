@@ -4,15 +4,47 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"net/url"
 	"time"
 )
 
 type Endpoint struct {
-	Host string `json:"host"    yaml:"host"    toml:"host"    mapstructure:"host"`
-	API  int    `json:"apiPort" yaml:"apiPort" toml:"apiPort" mapstructure:"apiPort"`
-	WEB  int    `json:"webPort" yaml:"webPort" toml:"webPort" mapstructure:"webPort"`
+	Host string         `json:"host"     yaml:"host"     toml:"host"     mapstructure:"host"`
+	API  int            `json:"apiPort"  yaml:"apiPort"  toml:"apiPort"  mapstructure:"apiPort"`
+	WEB  int            `json:"webPort"  yaml:"webPort"  toml:"webPort"  mapstructure:"webPort"`
+	TCP  int            `json:"tcpPort"  yaml:"tcpPort"  toml:"tcpPort"  mapstructure:"tcpPort"`
+	UDP  map[string]int `json:"udpPorts" yaml:"udpPorts" toml:"udpPorts" mapstructure:"udpPorts"`
+
+	PollInterval time.Duration `json:"pollInterval" yaml:"pollInterval" toml:"pollInterval" mapstructure:"pollInterval"`
+	Timeout      time.Duration `json:"timeout"      yaml:"timeout"      toml:"timeout"      mapstructure:"timeout"`
+	MaxBackoff   time.Duration `json:"maxBackoff"   yaml:"maxBackoff"   toml:"maxBackoff"   mapstructure:"maxBackoff"`
+
+	APIScheme string        `json:"apiScheme" yaml:"apiScheme" toml:"apiScheme" mapstructure:"apiScheme"`
+	TLS       *EndpointTLS  `json:"tls"       yaml:"tls"       toml:"tls"       mapstructure:"tls"`
+	Auth      *EndpointAuth `json:"auth"      yaml:"auth"      toml:"auth"      mapstructure:"auth"`
+
+	WebSecure int          `json:"webSecurePort" yaml:"webSecurePort" toml:"webSecurePort" mapstructure:"webSecurePort"`
+	WebTLS    *EndpointTLS `json:"webTLS"        yaml:"webTLS"        toml:"webTLS"        mapstructure:"webTLS"`
+
+	APIURL  string `json:"apiURL"  yaml:"apiURL"  toml:"apiURL"  mapstructure:"apiURL"`
+	WebURL  string `json:"webURL"  yaml:"webURL"  toml:"webURL"  mapstructure:"webURL"`
+	RawPath string `json:"rawPath" yaml:"rawPath" toml:"rawPath" mapstructure:"rawPath"`
+
+	Filter       *Filter `json:"filter"       yaml:"filter"       toml:"filter"       mapstructure:"filter"`
+	ExportMarker string  `json:"exportMarker" yaml:"exportMarker" toml:"exportMarker" mapstructure:"exportMarker"`
+
+	PriorityOffset    int               `json:"priorityOffset"    yaml:"priorityOffset"    toml:"priorityOffset"    mapstructure:"priorityOffset"`
+	EntryPoints       map[string]string `json:"entryPoints"       yaml:"entryPoints"       toml:"entryPoints"       mapstructure:"entryPoints"`
+	DefaultEntryPoint string            `json:"defaultEntryPoint" yaml:"defaultEntryPoint" toml:"defaultEntryPoint" mapstructure:"defaultEntryPoint"`
+
+	Weight int `json:"weight" yaml:"weight" toml:"weight" mapstructure:"weight"`
+
+	Domains     []string `json:"domains"     yaml:"domains"     toml:"domains"     mapstructure:"domains"`
+	StrictHosts bool     `json:"strictHosts" yaml:"strictHosts" toml:"strictHosts" mapstructure:"strictHosts"`
+
+	WildcardDomains []string `json:"wildcardDomains" yaml:"wildcardDomains" toml:"wildcardDomains" mapstructure:"wildcardDomains"`
 }
 
 type Config struct {
@@ -20,8 +52,43 @@ type Config struct {
 	PollInterval time.Duration `json:"pollInterval" yaml:"pollInterval" toml:"pollInterval" mapstructure:"pollInterval"`
 	Endpoints    []Endpoint    `json:"endpoints"    yaml:"endpoints"    toml:"endpoints"    mapstructure:"endpoints"`
 	TLSResolver  *string       `json:"tlsResolver"  yaml:"tlsResolver"  toml:"tlsResolver"  mapstructure:"tlsResolver"`
+	TLSOptions   string        `json:"tlsOptions"   yaml:"tlsOptions"   toml:"tlsOptions"   mapstructure:"tlsOptions"`
+	TLSRules     []TLSRule     `json:"tlsRules"     yaml:"tlsRules"     toml:"tlsRules"     mapstructure:"tlsRules"`
+	Redirect     *Redirect     `json:"redirect"     yaml:"redirect"     toml:"redirect"     mapstructure:"redirect"`
+	MaxStaleness time.Duration `json:"maxStaleness" yaml:"maxStaleness" toml:"maxStaleness" mapstructure:"maxStaleness"`
+	Lazy         bool          `json:"lazy"         yaml:"lazy"         toml:"lazy"         mapstructure:"lazy"`
+	MinHealthy   int           `json:"minHealthy"   yaml:"minHealthy"   toml:"minHealthy"   mapstructure:"minHealthy"`
+	Filter       *Filter       `json:"filter"       yaml:"filter"       toml:"filter"       mapstructure:"filter"`
+	ExportMarker string        `json:"exportMarker" yaml:"exportMarker" toml:"exportMarker" mapstructure:"exportMarker"`
+
+	CopyMiddlewares bool `json:"copyMiddlewares" yaml:"copyMiddlewares" toml:"copyMiddlewares" mapstructure:"copyMiddlewares"`
+	WeightByServers bool `json:"weightByServers" yaml:"weightByServers" toml:"weightByServers" mapstructure:"weightByServers"`
+
+	Aggregate      bool   `json:"aggregate"      yaml:"aggregate"      toml:"aggregate"      mapstructure:"aggregate"`
+	StickyCookie   string `json:"stickyCookie"   yaml:"stickyCookie"   toml:"stickyCookie"   mapstructure:"stickyCookie"`
+	ConflictPolicy string `json:"conflictPolicy" yaml:"conflictPolicy" toml:"conflictPolicy" mapstructure:"conflictPolicy"`
+
+	HostOwnership  bool          `json:"hostOwnership"  yaml:"hostOwnership"  toml:"hostOwnership"  mapstructure:"hostOwnership"`
+	OwnershipGrace time.Duration `json:"ownershipGrace" yaml:"ownershipGrace" toml:"ownershipGrace" mapstructure:"ownershipGrace"`
+
+	TLSDomains      bool     `json:"tlsDomains"      yaml:"tlsDomains"      toml:"tlsDomains"      mapstructure:"tlsDomains"`
+	WildcardDomains []string `json:"wildcardDomains" yaml:"wildcardDomains" toml:"wildcardDomains" mapstructure:"wildcardDomains"`
+
+	EntryPoints       map[string]string `json:"entryPoints"       yaml:"entryPoints"       toml:"entryPoints"       mapstructure:"entryPoints"`
+	DefaultEntryPoint string            `json:"defaultEntryPoint" yaml:"defaultEntryPoint" toml:"defaultEntryPoint" mapstructure:"defaultEntryPoint"`
 }
 
+// Policies applied to routers of different endpoints claiming the same requests.
+// Without a policy conflicts are only logged.
+const (
+	ConflictFirst    = "first"
+	ConflictPriority = "priority"
+	ConflictReject   = "reject"
+	ConflictMerge    = "merge"
+)
+
+var ErrNotEnoughHealthy = errors.New("not enough healthy endpoints")
+
 func (c *Config) Validate() error {
 	if c == nil {
 		return errors.New("empty config")
@@ -35,62 +102,319 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("wrong poll interval: %s", c.PollInterval)
 	}
 
+	if c.MaxStaleness < 0 {
+		return fmt.Errorf("wrong max staleness: %s", c.MaxStaleness)
+	}
+
 	if len(c.Endpoints) == 0 {
 		return errors.New("empty endpoints")
 	}
 
+	if c.MinHealthy < 0 || c.MinHealthy > len(c.Endpoints) {
+		return fmt.Errorf("wrong min healthy: %d of %d endpoints", c.MinHealthy, len(c.Endpoints))
+	}
+
+	if err := c.validateRouting(); err != nil {
+		return err
+	}
+
+	if err := c.validateTLS(); err != nil {
+		return err
+	}
+
+	if err := c.validateMerge(); err != nil {
+		return err
+	}
+
 	for i, endpoint := range c.Endpoints {
-		if endpoint.Host == "" {
-			return fmt.Errorf("empty #%d endpoint host", i)
+		if err := endpoint.validate(i); err != nil {
+			return err
 		}
+	}
 
-		if endpoint.API <= 0 {
-			return fmt.Errorf("empty #%d endpoint apiPort: %d", i, endpoint.API)
+	return nil
+}
+
+// validateMerge checks the settings applied when endpoint results are merged.
+func (c *Config) validateMerge() error {
+	switch c.ConflictPolicy {
+	case "", ConflictFirst, ConflictPriority, ConflictReject, ConflictMerge:
+	default:
+		return fmt.Errorf("wrong conflict policy: %q", c.ConflictPolicy)
+	}
+
+	if c.OwnershipGrace < 0 {
+		return fmt.Errorf("wrong ownership grace: %s", c.OwnershipGrace)
+	}
+
+	return nil
+}
+
+// validateRouting checks the settings applied to the routers of every endpoint.
+func (c *Config) validateRouting() error {
+	if _, err := c.Filter.compile(); err != nil {
+		return fmt.Errorf("wrong filter: %w", err)
+	}
+
+	if err := validateMarker(c.ExportMarker); err != nil {
+		return fmt.Errorf("wrong export marker: %w", err)
+	}
+
+	if err := validateEntryPoints(c.EntryPoints); err != nil {
+		return fmt.Errorf("wrong entrypoints: %w", err)
+	}
+
+	return nil
+}
+
+// validateTLS checks the settings of the generated secure routers.
+func (c *Config) validateTLS() error {
+	if err := validateWildcards(c.WildcardDomains); err != nil {
+		return fmt.Errorf("wrong wildcard domains: %w", err)
+	}
+
+	if _, err := compileTLSRules(c.TLSRules); err != nil {
+		return fmt.Errorf("wrong tls rules: %w", err)
+	}
+
+	if err := c.Redirect.validate(); err != nil {
+		return fmt.Errorf("wrong redirect: %w", err)
+	}
+
+	return nil
+}
+
+func (e Endpoint) validate(i int) error {
+	if err := e.validateAddress(i); err != nil {
+		return err
+	}
+
+	if e.TCP < 0 {
+		return fmt.Errorf("wrong #%d endpoint tcpPort: %d", i, e.TCP)
+	}
+
+	for entrypoint, port := range e.UDP {
+		if entrypoint == "" || port <= 0 {
+			return fmt.Errorf("wrong #%d endpoint udpPorts(%q): %d", i, entrypoint, port)
 		}
+	}
+
+	if e.PollInterval < 0 {
+		return fmt.Errorf("wrong #%d endpoint poll interval: %s", i, e.PollInterval)
+	}
 
-		if endpoint.WEB <= 0 {
-			return fmt.Errorf("empty #%d endpoint webPort: %d", i, endpoint.WEB)
+	if e.Timeout < 0 {
+		return fmt.Errorf("wrong #%d endpoint timeout: %s", i, e.Timeout)
+	}
+
+	if e.MaxBackoff < 0 {
+		return fmt.Errorf("wrong #%d endpoint max backoff: %s", i, e.MaxBackoff)
+	}
+
+	if e.Weight < 0 {
+		return fmt.Errorf("wrong #%d endpoint weight: %d", i, e.Weight)
+	}
+
+	if err := e.validateTLS(i); err != nil {
+		return err
+	}
+
+	if err := e.Auth.validate(); err != nil {
+		return fmt.Errorf("wrong #%d endpoint auth: %w", i, err)
+	}
+
+	return e.validateRouting(i)
+}
+
+// validateTLS checks the TLS settings of the API and of the secure web port.
+func (e Endpoint) validateTLS(i int) error {
+	switch e.scheme() {
+	case schemeHTTP:
+		if e.TLS != nil {
+			return fmt.Errorf("wrong #%d endpoint tls: requires apiScheme %q", i, schemeHTTPS)
 		}
+	case schemeHTTPS:
+		if _, err := e.TLS.Config(); err != nil {
+			return fmt.Errorf("wrong #%d endpoint tls: %w", i, err)
+		}
+	default:
+		return fmt.Errorf("wrong #%d endpoint apiScheme: %q", i, e.APIScheme)
+	}
+
+	if e.WebSecure < 0 {
+		return fmt.Errorf("wrong #%d endpoint webSecurePort: %d", i, e.WebSecure)
+	}
+
+	if e.WebTLS != nil && e.WebSecure == 0 {
+		return fmt.Errorf("wrong #%d endpoint webTLS: requires webSecurePort", i)
+	} else if _, err := e.WebTLS.Config(); err != nil {
+		return fmt.Errorf("wrong #%d endpoint webTLS: %w", i, err)
+	}
+
+	return nil
+}
+
+// validateRouting checks the settings applied to the routers of the endpoint.
+func (e Endpoint) validateRouting(i int) error {
+	if _, err := e.Filter.compile(); err != nil {
+		return fmt.Errorf("wrong #%d endpoint filter: %w", i, err)
+	}
+
+	if err := validateMarker(e.ExportMarker); err != nil {
+		return fmt.Errorf("wrong #%d endpoint export marker: %w", i, err)
+	}
+
+	if err := validateEntryPoints(e.EntryPoints); err != nil {
+		return fmt.Errorf("wrong #%d endpoint entrypoints: %w", i, err)
+	}
+
+	if err := validateDomains(e.Domains); err != nil {
+		return fmt.Errorf("wrong #%d endpoint domains: %w", i, err)
+	}
+
+	if err := validateWildcards(e.WildcardDomains); err != nil {
+		return fmt.Errorf("wrong #%d endpoint wildcard domains: %w", i, err)
+	}
+
+	return nil
+}
+
+func probe(ctx context.Context, cli *http.Client, uri *url.URL, auth *EndpointAuth) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri.String(), nil)
+	if err != nil {
+		return fmt.Errorf("could not prepare request(%s): %w", uri.String(), err)
+	}
+
+	if _, err = auth.apply(req); err != nil {
+		return fmt.Errorf("could not authorize request(%s): %w", uri.String(), err)
+	}
+
+	var res *http.Response
+	if res, err = cli.Do(req); err != nil {
+		return fmt.Errorf("could not call request(%s): %w", uri.String(), err)
+	}
+
+	if err = res.Body.Close(); err != nil {
+		return fmt.Errorf("could not close response body: %w", err)
 	}
 
 	return nil
 }
 
+func (e Endpoint) probe(ctx context.Context, cli *http.Client) error {
+	if err := probe(ctx, cli, e.apiBase(), e.Auth); err != nil {
+		return err
+	}
+
+	return probe(ctx, cli, e.webURL(), nil)
+}
+
+// withDefaults applies the global settings to the ones the endpoint leaves unset.
+func (c *Config) withDefaults(endpoint Endpoint) Endpoint {
+	if endpoint.PollInterval == 0 {
+		endpoint.PollInterval = c.PollInterval
+	}
+
+	if endpoint.Timeout == 0 {
+		endpoint.Timeout = c.ConnTimeout
+	}
+
+	if endpoint.Weight == 0 {
+		endpoint.Weight = 1
+	}
+
+	if endpoint.WildcardDomains == nil {
+		endpoint.WildcardDomains = c.WildcardDomains
+	}
+
+	if endpoint.EntryPoints == nil {
+		endpoint.EntryPoints = c.EntryPoints
+	}
+
+	if endpoint.DefaultEntryPoint == "" {
+		endpoint.DefaultEntryPoint = c.DefaultEntryPoint
+	}
+
+	return endpoint
+}
+
+// PrepareClients probes every endpoint and creates its client. In lazy mode
+// an unreachable endpoint is registered as pending instead of failing startup,
+// as long as at least MinHealthy endpoints respond.
 func (c *Config) PrepareClients(top context.Context) ([]*Client, error) {
-	ctx, cancel := context.WithTimeout(top, c.ConnTimeout)
-	defer cancel()
+	global, err := c.Filter.compile()
+	if err != nil {
+		return nil, fmt.Errorf("could not compile filter: %w", err)
+	}
+
+	tlsRules, err := compileTLSRules(c.TLSRules)
+	if err != nil {
+		return nil, fmt.Errorf("could not compile tls rules: %w", err)
+	}
+
+	var healthy int
 
 	cli := new(http.Client)
 	out := make([]*Client, 0, len(c.Endpoints))
 	for _, endpoint := range c.Endpoints {
-		var err error
-		for _, port := range []int{endpoint.API, endpoint.WEB} {
-			uri := url.URL{
-				Host:   fmt.Sprintf("%s:%d", endpoint.Host, port),
-				Scheme: "http",
-				Path:   "/",
-			}
+		endpoint.Host = endpoint.hostname()
 
-			var req *http.Request
-			if req, err = http.NewRequestWithContext(ctx, http.MethodGet, uri.String(), nil); err != nil {
-				return nil, fmt.Errorf("could not prepare request(%s): %w", uri.String(), err)
-			}
+		api, err := endpoint.httpClient(cli)
+		if err != nil {
+			return nil, fmt.Errorf("could not prepare client(%s): %w", endpoint.Host, err)
+		}
 
-			var res *http.Response
-			if res, err = cli.Do(req); err != nil {
-				return nil, fmt.Errorf("could not call request(%s): %w", uri.String(), err)
-			}
+		local, err := endpoint.Filter.compile()
+		if err != nil {
+			return nil, fmt.Errorf("could not compile filter(%s): %w", endpoint.Host, err)
+		}
+
+		client := &Client{
+			Client:   api,
+			resolver: c.TLSResolver,
+			maxStale: c.MaxStaleness,
+			marker:   c.ExportMarker,
+			copyMW:   c.CopyMiddlewares,
+			weighted: c.WeightByServers,
+
+			tlsOptions:  c.TLSOptions,
+			tlsRules:    tlsRules,
+			redirect:    c.Redirect,
+			certDomains: c.TLSDomains || len(c.WildcardDomains) > 0 || len(endpoint.WildcardDomains) > 0,
+		}
 
-			if err = res.Body.Close(); err != nil {
-				return nil, fmt.Errorf("could not close response body: %w", err)
+		if endpoint.ExportMarker != "" {
+			client.marker = endpoint.ExportMarker
+		}
+
+		for _, filter := range []*routerFilter{global, local} {
+			if filter != nil {
+				client.filters = append(client.filters, filter)
 			}
 		}
 
-		out = append(out, &Client{
-			Client:   cli,
-			endpoint: endpoint,
-			resolver: c.TLSResolver,
-		})
+		// every endpoint gets its own deadline, so a hanging one does not fail the next ones
+		endpoint = c.withDefaults(endpoint)
+		ctx, cancel := context.WithTimeout(top, endpoint.Timeout)
+		err = endpoint.probe(ctx, api)
+		cancel()
+
+		if err == nil {
+			healthy++
+		} else if !c.Lazy {
+			return nil, err
+		} else {
+			log.Printf("endpoint %q is pending: %s", endpoint.Host, err)
+
+			client.pending.Store(true)
+		}
+
+		client.endpoint = endpoint
+		out = append(out, client)
+	}
+
+	if healthy < c.MinHealthy {
+		return nil, fmt.Errorf("%w: %d of %d required", ErrNotEnoughHealthy, healthy, c.MinHealthy)
 	}
 
 	return out, nil
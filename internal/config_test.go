@@ -1,6 +1,9 @@
 package internal
 
 import (
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -23,9 +26,17 @@ func TestConfig_validate(t *testing.T) {
 	require.ErrorContains(t, cfg.Validate(), "wrong poll interval")
 
 	cfg.PollInterval = defaultTestPollInterval
+	cfg.MaxStaleness = -time.Second
+	require.ErrorContains(t, cfg.Validate(), "wrong max staleness")
+
+	cfg.MaxStaleness = time.Minute
 	require.ErrorContains(t, cfg.Validate(), "empty endpoints")
 
 	cfg.Endpoints = make([]Endpoint, 1)
+	cfg.MinHealthy = 2
+	require.ErrorContains(t, cfg.Validate(), "wrong min healthy")
+
+	cfg.MinHealthy = 1
 	require.ErrorContains(t, cfg.Validate(), "empty #0 endpoint host")
 
 	cfg.Endpoints[0].Host = "localhost"
@@ -35,5 +46,163 @@ func TestConfig_validate(t *testing.T) {
 	require.ErrorContains(t, cfg.Validate(), "empty #0 endpoint webPort")
 
 	cfg.Endpoints[0].WEB = 8080
+	cfg.Endpoints[0].TCP = -1
+	require.ErrorContains(t, cfg.Validate(), "wrong #0 endpoint tcpPort")
+
+	cfg.Endpoints[0].TCP = 0
+	cfg.Endpoints[0].UDP = map[string]int{"dns": 0}
+	require.ErrorContains(t, cfg.Validate(), "wrong #0 endpoint udpPorts")
+
+	cfg.Endpoints[0].UDP = map[string]int{"dns": 53}
+	cfg.Endpoints[0].PollInterval = -time.Second
+	require.ErrorContains(t, cfg.Validate(), "wrong #0 endpoint poll interval")
+
+	cfg.Endpoints[0].PollInterval = 0
+	cfg.Endpoints[0].Timeout = -time.Second
+	require.ErrorContains(t, cfg.Validate(), "wrong #0 endpoint timeout")
+
+	cfg.Endpoints[0].Timeout = 0
+	cfg.Endpoints[0].MaxBackoff = -time.Second
+	require.ErrorContains(t, cfg.Validate(), "wrong #0 endpoint max backoff")
+
+	cfg.Endpoints[0].MaxBackoff = 0
+	cfg.Endpoints[0].Weight = -1
+	require.ErrorContains(t, cfg.Validate(), "wrong #0 endpoint weight")
+
+	cfg.Endpoints[0].Weight = 0
+	cfg.Endpoints[0].WebSecure = -1
+	require.ErrorContains(t, cfg.Validate(), "wrong #0 endpoint webSecurePort")
+
+	cfg.Endpoints[0].WebSecure = 0
+	cfg.Endpoints[0].WebTLS = &EndpointTLS{}
+	require.ErrorContains(t, cfg.Validate(), "wrong #0 endpoint webTLS: requires webSecurePort")
+
+	cfg.Endpoints[0].WebSecure = 443
+	cfg.Endpoints[0].WebTLS = &EndpointTLS{Cert: "cert.pem"}
+	require.ErrorContains(t, cfg.Validate(), "wrong #0 endpoint webTLS")
+
+	cfg.Endpoints[0].WebTLS = nil
+	cfg.Endpoints[0].Domains = []string{"*"}
+	require.ErrorContains(t, cfg.Validate(), "wrong #0 endpoint domains")
+
+	cfg.Endpoints[0].Domains = nil
+	cfg.Endpoints[0].Filter = &Filter{Include: &FilterRules{Routers: []string{"re:("}}}
+	require.ErrorContains(t, cfg.Validate(), "wrong #0 endpoint filter")
+
+	cfg.Endpoints[0].Filter = nil
+	cfg.Filter = &Filter{Exclude: &FilterRules{Providers: []string{""}}}
+	require.ErrorContains(t, cfg.Validate(), "wrong filter")
+
+	cfg.Filter = nil
+	cfg.ExportMarker = "@file"
+	require.ErrorContains(t, cfg.Validate(), "wrong export marker")
+
+	cfg.ExportMarker = ""
+	cfg.Endpoints[0].ExportMarker = "export@"
+	require.ErrorContains(t, cfg.Validate(), "wrong #0 endpoint export marker")
+
+	cfg.Endpoints[0].ExportMarker = "export-central@file"
+	cfg.EntryPoints = map[string]string{"web": ""}
+	require.ErrorContains(t, cfg.Validate(), "wrong entrypoints")
+
+	cfg.EntryPoints = map[string]string{"web": "public"}
+	cfg.ConflictPolicy = "last"
+	require.ErrorContains(t, cfg.Validate(), "wrong conflict policy")
+
+	cfg.ConflictPolicy = ConflictFirst
+	cfg.OwnershipGrace = -time.Second
+	require.ErrorContains(t, cfg.Validate(), "wrong ownership grace")
+
+	cfg.OwnershipGrace = time.Minute
+	cfg.WildcardDomains = []string{"example.com"}
+	require.ErrorContains(t, cfg.Validate(), "wrong wildcard domains")
+
+	cfg.WildcardDomains = nil
+	cfg.TLSRules = []TLSRule{{CertResolver: "letsencrypt"}}
+	require.ErrorContains(t, cfg.Validate(), "wrong tls rules")
+
+	cfg.TLSRules = nil
+	cfg.Redirect = &Redirect{Port: "https"}
+	require.ErrorContains(t, cfg.Validate(), "wrong redirect")
+
+	cfg.Redirect = nil
+	cfg.Endpoints[0].WildcardDomains = []string{"*.*.example.com"}
+	require.ErrorContains(t, cfg.Validate(), "wrong #0 endpoint wildcard domains")
+
+	cfg.Endpoints[0].WildcardDomains = nil
+	cfg.Endpoints[0].EntryPoints = map[string]string{"": "internal"}
+	require.ErrorContains(t, cfg.Validate(), "wrong #0 endpoint entrypoints")
+
+	cfg.Endpoints[0].EntryPoints = nil
 	require.NoError(t, cfg.Validate())
 }
+
+func TestConfig_PrepareClients_lazy(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	addr, ok := srv.Listener.Addr().(*net.TCPAddr)
+	require.True(t, ok)
+
+	srv.Close()
+
+	cfg := Config{
+		ConnTimeout:  defaultTestConnTimeout,
+		PollInterval: defaultTestPollInterval,
+		Endpoints: []Endpoint{{
+			Host: addr.IP.String(),
+			API:  addr.Port,
+			WEB:  addr.Port,
+		}},
+	}
+
+	_, err := cfg.PrepareClients(t.Context())
+	require.ErrorContains(t, err, "could not call request")
+
+	cfg.Lazy = true
+	cli, err := cfg.PrepareClients(t.Context())
+	require.NoError(t, err)
+	require.Len(t, cli, 1)
+	require.True(t, cli[0].pending.Load())
+
+	cfg.MinHealthy = 1
+	_, err = cfg.PrepareClients(t.Context())
+	require.ErrorIs(t, err, ErrNotEnoughHealthy)
+}
+
+func TestConfig_PrepareClients_hanging(t *testing.T) {
+	done := make(chan struct{})
+	hanging := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		<-done
+	}))
+	t.Cleanup(hanging.Close)
+	t.Cleanup(func() { close(done) })
+
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(healthy.Close)
+
+	var endpoints []Endpoint
+	for _, srv := range []*httptest.Server{hanging, healthy} {
+		addr, ok := srv.Listener.Addr().(*net.TCPAddr)
+		require.True(t, ok)
+
+		endpoints = append(endpoints, Endpoint{Host: addr.IP.String(), API: addr.Port, WEB: addr.Port})
+	}
+
+	cfg := Config{
+		ConnTimeout:  100 * time.Millisecond,
+		PollInterval: defaultTestPollInterval,
+		Endpoints:    endpoints,
+		Lazy:         true,
+		MinHealthy:   1,
+	}
+
+	cli, err := cfg.PrepareClients(t.Context())
+	require.NoError(t, err)
+	require.Len(t, cli, 2)
+	require.True(t, cli[0].pending.Load())
+	require.False(t, cli[1].pending.Load())
+}
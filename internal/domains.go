@@ -0,0 +1,73 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+const wildcardPrefix = "*."
+
+func validateDomains(domains []string) error {
+	for _, domain := range domains {
+		if name := strings.TrimPrefix(domain, wildcardPrefix); name == "" || strings.Contains(name, "*") {
+			return fmt.Errorf("expected example.com or *.example.com, got %q", domain)
+		}
+	}
+
+	return nil
+}
+
+// allowsHost reports whether the host is one of the endpoint domains.
+// A wildcard domain matches subdomains of any depth, but not itself.
+func (e Endpoint) allowsHost(host string) bool {
+	for _, domain := range e.Domains {
+		domain = strings.ToLower(domain)
+		if suffix, ok := strings.CutPrefix(domain, "*"); ok {
+			if strings.HasSuffix(host, suffix) {
+				return true
+			}
+		} else if host == domain {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkHosts verifies the host matchers of a rule against the endpoint domains.
+// HostRegexp matchers can not be verified, so they are rejected when domains are set.
+func (e Endpoint) checkHosts(rule string) error {
+	if e.StrictHosts && !hostBound(rule) {
+		return errors.New("rule is not bound to a host")
+	}
+
+	if len(e.Domains) == 0 {
+		return nil
+	}
+
+	matchers := ruleMatchers(rule)
+	if values := matchers[matcherHostRegexp]; len(values) > 0 {
+		return fmt.Errorf("HostRegexp %q can not be checked against domains", values[0])
+	}
+
+	for _, host := range append(matchers[matcherHost], matchers[matcherHostSNI]...) {
+		if !e.allowsHost(host) {
+			return fmt.Errorf("host %q is not in domains", host)
+		}
+	}
+
+	return nil
+}
+
+// allowed applies the endpoint domains to a router.
+func (c *Client) allowed(kind, key, rule string) bool {
+	if err := c.endpoint.checkHosts(rule); err != nil {
+		log.Printf("skip %s %q(client:%q): %s", kind, key, c.endpoint.Host, err)
+
+		return false
+	}
+
+	return true
+}
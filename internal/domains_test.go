@@ -0,0 +1,55 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/genconf/dynamic"
+)
+
+func TestValidateDomains(t *testing.T) {
+	require.NoError(t, validateDomains([]string{"example.com", "*.lab.example.com"}))
+	require.Error(t, validateDomains([]string{""}))
+	require.Error(t, validateDomains([]string{"*."}))
+	require.Error(t, validateDomains([]string{"a.*.example.com"}))
+}
+
+func TestEndpoint_checkHosts(t *testing.T) {
+	var endpoint Endpoint
+	require.NoError(t, endpoint.checkHosts("PathPrefix(`/`)"))
+
+	endpoint.StrictHosts = true
+	require.ErrorContains(t, endpoint.checkHosts("PathPrefix(`/`)"), "not bound to a host")
+	require.ErrorContains(t, endpoint.checkHosts("Host(`a.lan`) || PathPrefix(`/`)"), "not bound to a host")
+	require.NoError(t, endpoint.checkHosts("Host(`bank.example.com`)"))
+
+	endpoint.Domains = []string{"app.example.com", "*.Lab.example.com"}
+	require.NoError(t, endpoint.checkHosts("Host(`app.example.com`)"))
+	require.NoError(t, endpoint.checkHosts("Host(`a.lab.example.com`) || Host(`b.c.lab.example.com`)"))
+	require.NoError(t, endpoint.checkHosts("HostSNI(`db.lab.example.com`)"))
+	require.ErrorContains(t, endpoint.checkHosts("Host(`bank.example.com`)"), `host "bank.example.com" is not in domains`)
+	require.ErrorContains(t, endpoint.checkHosts("Host(`lab.example.com`)"), "not in domains")
+	require.ErrorContains(t, endpoint.checkHosts("Host(`app.example.com`) || Host(`evil.com`)"), "not in domains")
+	require.ErrorContains(t, endpoint.checkHosts("HostRegexp(`.+`)"), "can not be checked")
+
+	endpoint.StrictHosts = false
+	require.NoError(t, endpoint.checkHosts("PathPrefix(`/`)"))
+	require.ErrorContains(t, endpoint.checkHosts("HostSNI(`*`)"), "not in domains")
+}
+
+func TestClient_domains(t *testing.T) {
+	res := &rawData{
+		Routers: map[string]*rawRouter{
+			"app@docker":  {Router: dynamic.Router{Service: "backend", Rule: "Host(`app.lab.example.com`)"}},
+			"bank@docker": {Router: dynamic.Router{Service: "backend", Rule: "Host(`bank.example.com`)"}},
+		},
+		Services: map[string]*rawService{
+			"backend@docker": {Service: dynamic.Service{LoadBalancer: &dynamic.ServersLoadBalancer{}}},
+		},
+	}
+
+	cli := &Client{endpoint: Endpoint{Host: "worker", WEB: 80, Domains: []string{"*.lab.example.com"}}}
+	cfg := cli.prepareResponse(res)
+	require.Len(t, cfg.HTTP.Routers, 1)
+	require.Contains(t, cfg.HTTP.Routers, "app-docker-worker")
+}
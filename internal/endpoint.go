@@ -0,0 +1,138 @@
+package internal
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"strconv"
+)
+
+func (e Endpoint) scheme() string {
+	if e.APIURL != "" {
+		if uri, err := url.Parse(e.APIURL); err == nil {
+			return uri.Scheme
+		}
+	}
+
+	if e.APIScheme == "" {
+		return schemeHTTP
+	}
+
+	return e.APIScheme
+}
+
+// hostname returns the host of the endpoint, falling back to the one of webURL or apiURL.
+func (e Endpoint) hostname() string {
+	if e.Host != "" {
+		return e.Host
+	}
+
+	for _, raw := range []string{e.WebURL, e.APIURL} {
+		if uri, err := url.Parse(raw); err == nil && uri.Hostname() != "" {
+			return uri.Hostname()
+		}
+	}
+
+	return ""
+}
+
+func (e Endpoint) rawPath() string {
+	if e.RawPath == "" {
+		return defaultRawPath
+	}
+
+	return e.RawPath
+}
+
+// apiBase returns the root of the remote Traefik API.
+func (e Endpoint) apiBase() *url.URL {
+	if uri, err := url.Parse(e.APIURL); err == nil && e.APIURL != "" {
+		return uri
+	}
+
+	return &url.URL{Scheme: e.scheme(), Host: net.JoinHostPort(e.Host, strconv.Itoa(e.API)), Path: "/"}
+}
+
+// rawURL returns the address of the rawdata endpoint.
+func (e Endpoint) rawURL() *url.URL {
+	uri := e.apiBase()
+	uri.Path = path.Join("/", uri.Path, e.rawPath())
+
+	return uri
+}
+
+// webURL returns the address that generated services forward traffic to.
+func (e Endpoint) webURL() *url.URL {
+	if uri, err := url.Parse(e.WebURL); err == nil && e.WebURL != "" {
+		return &url.URL{Scheme: uri.Scheme, Host: uri.Host}
+	}
+
+	return &url.URL{Scheme: schemeHTTP, Host: net.JoinHostPort(e.Host, strconv.Itoa(e.WEB))}
+}
+
+func parseURL(raw string) (*url.URL, error) {
+	uri, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case uri.Scheme != schemeHTTP && uri.Scheme != schemeHTTPS:
+		return nil, fmt.Errorf("unsupported scheme %q", uri.Scheme)
+	case uri.Hostname() == "":
+		return nil, fmt.Errorf("empty host in %q", raw)
+	case uri.User != nil, uri.RawQuery != "", uri.Fragment != "":
+		return nil, fmt.Errorf("unexpected userinfo, query or fragment in %q", raw)
+	}
+
+	return uri, nil
+}
+
+// validateAddress checks that the API and web addresses are given either
+// as host and port or as a full URL, but not both.
+func (e Endpoint) validateAddress(i int) error {
+	if e.Host == "" && (e.APIURL == "" || e.WebURL == "") {
+		return fmt.Errorf("empty #%d endpoint host", i)
+	}
+
+	if e.APIURL == "" && e.API <= 0 {
+		return fmt.Errorf("empty #%d endpoint apiPort: %d", i, e.API)
+	}
+
+	if e.WebURL == "" && e.WEB <= 0 {
+		return fmt.Errorf("empty #%d endpoint webPort: %d", i, e.WEB)
+	}
+
+	if e.APIURL != "" {
+		if e.API != 0 || e.APIScheme != "" {
+			return fmt.Errorf("wrong #%d endpoint apiURL: conflicts with apiPort and apiScheme", i)
+		} else if _, err := parseURL(e.APIURL); err != nil {
+			return fmt.Errorf("wrong #%d endpoint apiURL: %w", i, err)
+		}
+	}
+
+	if e.WebURL != "" {
+		if e.WEB != 0 {
+			return fmt.Errorf("wrong #%d endpoint webURL: conflicts with webPort", i)
+		}
+
+		uri, err := parseURL(e.WebURL)
+		if err != nil {
+			return fmt.Errorf("wrong #%d endpoint webURL: %w", i, err)
+		} else if uri.Path != "" && uri.Path != "/" {
+			return fmt.Errorf("wrong #%d endpoint webURL: path %q is not supported", i, uri.Path)
+		}
+	}
+
+	if e.RawPath != "" && !path.IsAbs(e.RawPath) {
+		return fmt.Errorf("wrong #%d endpoint rawPath: %q must start with /", i, e.RawPath)
+	}
+
+	return nil
+}
+
+// webSecureURL returns the address that services of TLS routers forward traffic to.
+func (e Endpoint) webSecureURL() *url.URL {
+	return &url.URL{Scheme: schemeHTTPS, Host: net.JoinHostPort(e.webURL().Hostname(), strconv.Itoa(e.WebSecure))}
+}
@@ -0,0 +1,103 @@
+package internal
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/genconf/dynamic"
+)
+
+func TestEndpoint_urls(t *testing.T) {
+	endpoint := Endpoint{Host: "fd00::1", API: 8080, WEB: 80}
+	require.Equal(t, "http://[fd00::1]:8080/api/rawdata", endpoint.rawURL().String())
+	require.Equal(t, "http://[fd00::1]:80", endpoint.webURL().String())
+	require.Equal(t, "fd00::1", endpoint.hostname())
+
+	endpoint.RawPath = "/traefik/api/rawdata"
+	require.Equal(t, "http://[fd00::1]:8080/traefik/api/rawdata", endpoint.rawURL().String())
+
+	endpoint = Endpoint{
+		APIURL: "https://worker.example.com/traefik",
+		WebURL: "http://worker.example.com:8000/",
+	}
+	require.Equal(t, "https", endpoint.scheme())
+	require.Equal(t, "worker.example.com", endpoint.hostname())
+	require.Equal(t, "https://worker.example.com/traefik/api/rawdata", endpoint.rawURL().String())
+	require.Equal(t, "http://worker.example.com:8000", endpoint.webURL().String())
+}
+
+func TestEndpoint_validateAddress(t *testing.T) {
+	cases := []struct {
+		endpoint Endpoint
+		expect   string
+	}{
+		{Endpoint{APIURL: "http://worker"}, "empty #0 endpoint host"},
+		{Endpoint{Host: "worker", WEB: 80}, "empty #0 endpoint apiPort"},
+		{Endpoint{Host: "worker", API: 80}, "empty #0 endpoint webPort"},
+		{Endpoint{APIURL: "ftp://worker", WebURL: "http://worker"}, "unsupported scheme"},
+		{Endpoint{APIURL: "http://:8080", WebURL: "http://worker"}, "empty host"},
+		{Endpoint{APIURL: "http://worker?x=1", WebURL: "http://worker"}, "unexpected userinfo, query or fragment"},
+		{Endpoint{APIURL: "http://worker", API: 8080, WebURL: "http://worker"}, "conflicts with apiPort"},
+		{Endpoint{APIURL: "http://worker", WebURL: "http://worker", WEB: 80}, "conflicts with webPort"},
+		{Endpoint{APIURL: "http://worker", WebURL: "http://worker/app"}, `path "/app" is not supported`},
+		{Endpoint{APIURL: "http://worker", WebURL: "http://worker", RawPath: "api"}, "must start with /"},
+		{Endpoint{APIURL: "http://[::1]:8080", WebURL: "http://[::1]:80"}, ""},
+		{Endpoint{Host: "::1", API: 8080, WEB: 80}, ""},
+	}
+
+	for _, item := range cases {
+		if err := item.endpoint.validateAddress(0); item.expect == "" {
+			require.NoError(t, err)
+		} else {
+			require.ErrorContains(t, err, item.expect)
+		}
+	}
+}
+
+func TestClient_apiURL(t *testing.T) {
+	data, err := os.ReadFile("../fixtures/jaeger-api-rawdata.json")
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/", "/traefik":
+			w.WriteHeader(http.StatusOK)
+		case "/traefik/api/rawdata":
+			w.WriteHeader(http.StatusOK)
+			assert.NoError(t, catchError(w.Write(data)))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	addr, ok := srv.Listener.Addr().(*net.TCPAddr)
+	require.True(t, ok)
+
+	cfg := Config{
+		ConnTimeout:  defaultTestConnTimeout,
+		PollInterval: defaultTestPollInterval,
+		Endpoints: []Endpoint{{
+			APIURL: srv.URL + "/traefik",
+			WebURL: srv.URL,
+		}},
+	}
+
+	require.NoError(t, cfg.Validate())
+
+	cli, err := cfg.PrepareClients(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, addr.IP.String(), cli[0].Endpoint())
+
+	out := make(chan *dynamic.Configuration, 1)
+	require.NoError(t, cli[0].FetchRaw(t.Context(), out))
+
+	result := <-out
+	require.NotNil(t, result.HTTP)
+	require.Equal(t, []dynamic.Server{{URL: srv.URL}},
+		result.HTTP.Services["whoami-docker-"+addr.IP.String()].LoadBalancer.Servers)
+}
@@ -0,0 +1,102 @@
+package internal
+
+import (
+	"fmt"
+	"log"
+	"slices"
+)
+
+// mapsEntryPoints reports whether generated routers are bound to explicit
+// central entrypoints instead of all of them.
+func (e Endpoint) mapsEntryPoints() bool {
+	return len(e.EntryPoints) > 0 || e.DefaultEntryPoint != ""
+}
+
+// centralEntryPoint returns the central entrypoint of a remote one.
+// Unmapped entrypoints go to DefaultEntryPoint or are dropped when it is empty.
+func (e Endpoint) centralEntryPoint(remote string) (string, bool) {
+	if name, ok := e.EntryPoints[remote]; ok {
+		return name, true
+	}
+
+	return e.DefaultEntryPoint, e.DefaultEntryPoint != ""
+}
+
+// entryPoints translates the remote entrypoints of a router. A router without
+// entrypoints listens on every remote one, so it is bound to every mapped entrypoint.
+func (c *Client) entryPoints(kind, key string, remote []string) ([]string, bool) {
+	if !c.endpoint.mapsEntryPoints() {
+		return nil, true
+	}
+
+	var out []string
+	if len(remote) == 0 {
+		for _, name := range c.endpoint.EntryPoints {
+			out = append(out, name)
+		}
+
+		if c.endpoint.DefaultEntryPoint != "" {
+			out = append(out, c.endpoint.DefaultEntryPoint)
+		}
+	}
+
+	for _, entrypoint := range remote {
+		if name, ok := c.endpoint.centralEntryPoint(entrypoint); ok {
+			out = append(out, name)
+		}
+	}
+
+	slices.Sort(out)
+	out = slices.Compact(out)
+
+	if len(out) == 0 {
+		log.Printf("skip %s %q(client:%q): entrypoints %q are not mapped", kind, key, c.endpoint.Host, remote)
+
+		return nil, false
+	}
+
+	return out, true
+}
+
+// tcpEntryPoints translates the remote entrypoints of a TCP router. An unbound
+// TCP router would catch the plain traffic of every central entrypoint, so the
+// remote names are kept when no mapping is configured.
+func (c *Client) tcpEntryPoints(key string, remote []string) ([]string, bool) {
+	if c.endpoint.mapsEntryPoints() {
+		return c.entryPoints("tcp router", key, remote)
+	} else if len(remote) == 0 {
+		log.Printf("skip tcp router %q(client:%q): no entrypoints to bind to", key, c.endpoint.Host)
+
+		return nil, false
+	}
+
+	return remote, true
+}
+
+// priority returns the priority of a generated router. Without an explicit
+// remote priority the offset is applied to Traefik's default, the rule length.
+func (e Endpoint) priority(remote int, rule string) int {
+	if e.PriorityOffset == 0 {
+		return remote
+	}
+
+	if remote == 0 {
+		remote = len(rule)
+	}
+
+	if remote += e.PriorityOffset; remote < 1 {
+		return 1
+	}
+
+	return remote
+}
+
+func validateEntryPoints(mapping map[string]string) error {
+	for remote, central := range mapping {
+		if remote == "" || central == "" {
+			return fmt.Errorf("expected remote and central names, got %q: %q", remote, central)
+		}
+	}
+
+	return nil
+}
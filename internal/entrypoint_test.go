@@ -0,0 +1,74 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_entryPoints(t *testing.T) {
+	cli := &Client{endpoint: Endpoint{Host: "worker"}}
+
+	out, ok := cli.entryPoints("router", "app@docker", []string{"web"})
+	require.True(t, ok)
+	require.Nil(t, out, "without mapping routers are bound to every central entrypoint")
+
+	cli.endpoint.EntryPoints = map[string]string{"web": "public", "websecure": "public", "lan": "internal"}
+
+	out, ok = cli.entryPoints("router", "app@docker", []string{"websecure", "web", "lan"})
+	require.True(t, ok)
+	require.Equal(t, []string{"internal", "public"}, out)
+
+	out, ok = cli.entryPoints("router", "app@docker", []string{"web", "metrics"})
+	require.True(t, ok)
+	require.Equal(t, []string{"public"}, out)
+
+	_, ok = cli.entryPoints("router", "app@docker", []string{"metrics"})
+	require.False(t, ok)
+
+	out, ok = cli.entryPoints("router", "app@docker", nil)
+	require.True(t, ok)
+	require.Equal(t, []string{"internal", "public"}, out)
+
+	cli.endpoint.DefaultEntryPoint = "internal"
+
+	out, ok = cli.entryPoints("router", "app@docker", []string{"web", "metrics"})
+	require.True(t, ok)
+	require.Equal(t, []string{"internal", "public"}, out)
+
+	cli.endpoint.EntryPoints = nil
+
+	out, ok = cli.entryPoints("router", "app@docker", []string{"web"})
+	require.True(t, ok)
+	require.Equal(t, []string{"internal"}, out)
+}
+
+func TestClient_tcpEntryPoints(t *testing.T) {
+	cli := &Client{endpoint: Endpoint{Host: "worker"}}
+
+	out, ok := cli.tcpEntryPoints("mqtt@docker", []string{"mqtt"})
+	require.True(t, ok)
+	require.Equal(t, []string{"mqtt"}, out, "without mapping routers keep the remote entrypoints")
+
+	_, ok = cli.tcpEntryPoints("mqtt@docker", nil)
+	require.False(t, ok)
+
+	cli.endpoint.DefaultEntryPoint = "internal"
+
+	out, ok = cli.tcpEntryPoints("mqtt@docker", nil)
+	require.True(t, ok)
+	require.Equal(t, []string{"internal"}, out)
+}
+
+func TestEndpoint_priority(t *testing.T) {
+	var endpoint Endpoint
+	require.Equal(t, 0, endpoint.priority(0, "Host(`a`)"))
+	require.Equal(t, 42, endpoint.priority(42, "Host(`a`)"))
+
+	endpoint.PriorityOffset = 100
+	require.Equal(t, 142, endpoint.priority(42, "Host(`a`)"))
+	require.Equal(t, 109, endpoint.priority(0, "Host(`a`)"))
+
+	endpoint.PriorityOffset = -100
+	require.Equal(t, 1, endpoint.priority(42, "Host(`a`)"))
+}
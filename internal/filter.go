@@ -0,0 +1,190 @@
+package internal
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// patternRegexp marks a pattern as a regular expression instead of a glob.
+const patternRegexp = "re:"
+
+// FilterRules lists patterns matched against exported routers.
+// Patterns are globs (path.Match syntax) or regular expressions prefixed with "re:".
+type FilterRules struct {
+	Providers   []string `json:"providers"   yaml:"providers"   toml:"providers"   mapstructure:"providers"`
+	Routers     []string `json:"routers"     yaml:"routers"     toml:"routers"     mapstructure:"routers"`
+	EntryPoints []string `json:"entryPoints" yaml:"entryPoints" toml:"entryPoints" mapstructure:"entryPoints"`
+	Hosts       []string `json:"hosts"       yaml:"hosts"       toml:"hosts"       mapstructure:"hosts"`
+}
+
+// Filter selects which remote routers are exported. A router has to match
+// every non-empty Include category and none of the Exclude ones.
+type Filter struct {
+	Include *FilterRules `json:"include" yaml:"include" toml:"include" mapstructure:"include"`
+	Exclude *FilterRules `json:"exclude" yaml:"exclude" toml:"exclude" mapstructure:"exclude"`
+}
+
+type pattern func(string) bool
+
+type patterns []pattern
+
+type rules struct {
+	providers   patterns
+	routers     patterns
+	entryPoints patterns
+	hosts       patterns
+}
+
+type routerFilter struct {
+	include rules
+	exclude rules
+}
+
+// candidate describes a remote router for filtering.
+type candidate struct {
+	key         string
+	entryPoints []string
+	hosts       []string
+}
+
+func compilePattern(expr string) (pattern, error) {
+	if strings.HasPrefix(expr, patternRegexp) {
+		re, err := regexp.Compile(strings.TrimPrefix(expr, patternRegexp))
+		if err != nil {
+			return nil, err
+		}
+
+		return re.MatchString, nil
+	}
+
+	if _, err := path.Match(expr, ""); err != nil {
+		return nil, fmt.Errorf("%w: %q", err, expr)
+	}
+
+	return func(val string) bool {
+		ok, _ := path.Match(expr, val)
+
+		return ok
+	}, nil
+}
+
+func compilePatterns(kind string, items []string) (patterns, error) {
+	out := make(patterns, 0, len(items))
+	for _, item := range items {
+		if item == "" {
+			return nil, fmt.Errorf("empty %s pattern", kind)
+		}
+
+		if kind == "providers" {
+			item = strings.TrimPrefix(item, "@")
+		}
+
+		match, err := compilePattern(item)
+		if err != nil {
+			return nil, fmt.Errorf("wrong %s pattern: %w", kind, err)
+		}
+
+		out = append(out, match)
+	}
+
+	return out, nil
+}
+
+func (r *FilterRules) compile() (rules, error) {
+	var (
+		out rules
+		err error
+	)
+
+	if r == nil {
+		return out, nil
+	}
+
+	if out.providers, err = compilePatterns("providers", r.Providers); err != nil {
+		return out, err
+	}
+
+	if out.routers, err = compilePatterns("routers", r.Routers); err != nil {
+		return out, err
+	}
+
+	if out.entryPoints, err = compilePatterns("entryPoints", r.EntryPoints); err != nil {
+		return out, err
+	}
+
+	if out.hosts, err = compilePatterns("hosts", r.Hosts); err != nil {
+		return out, err
+	}
+
+	return out, nil
+}
+
+func (f *Filter) compile() (*routerFilter, error) {
+	if f == nil {
+		return nil, nil
+	}
+
+	include, err := f.Include.compile()
+	if err != nil {
+		return nil, fmt.Errorf("include: %w", err)
+	}
+
+	exclude, err := f.Exclude.compile()
+	if err != nil {
+		return nil, fmt.Errorf("exclude: %w", err)
+	}
+
+	return &routerFilter{include: include, exclude: exclude}, nil
+}
+
+func (p patterns) any(values ...string) bool {
+	return slices.ContainsFunc(values, func(val string) bool {
+		return slices.ContainsFunc(p, func(match pattern) bool { return match(val) })
+	})
+}
+
+func (c candidate) name() string {
+	name, _, _ := strings.Cut(c.key, "@")
+
+	return name
+}
+
+func (c candidate) provider() string {
+	_, provider, _ := strings.Cut(c.key, "@")
+
+	return provider
+}
+
+// allow reports whether the router may be exported and the reason when it may not.
+func (f *routerFilter) allow(item candidate) (bool, string) {
+	if f == nil {
+		return true, ""
+	}
+
+	checks := []struct {
+		kind    string
+		include patterns
+		exclude patterns
+		values  []string
+	}{
+		{"provider", f.include.providers, f.exclude.providers, []string{item.provider()}},
+		{"router", f.include.routers, f.exclude.routers, []string{item.name()}},
+		{"entrypoint", f.include.entryPoints, f.exclude.entryPoints, item.entryPoints},
+		{"host", f.include.hosts, f.exclude.hosts, item.hosts},
+	}
+
+	for _, check := range checks {
+		if len(check.include) > 0 && !check.include.any(check.values...) {
+			return false, fmt.Sprintf("%s is not included", check.kind)
+		}
+
+		if check.exclude.any(check.values...) {
+			return false, fmt.Sprintf("%s is excluded", check.kind)
+		}
+	}
+
+	return true, ""
+}
@@ -0,0 +1,66 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilter_compile(t *testing.T) {
+	var empty *Filter
+
+	res, err := empty.compile()
+	require.NoError(t, err)
+	require.Nil(t, res)
+
+	_, err = (&Filter{Include: &FilterRules{Routers: []string{"re:("}}}).compile()
+	require.ErrorContains(t, err, "include: wrong routers pattern")
+
+	_, err = (&Filter{Exclude: &FilterRules{Hosts: []string{"[a-"}}}).compile()
+	require.ErrorContains(t, err, "exclude: wrong hosts pattern")
+
+	_, err = (&Filter{Exclude: &FilterRules{EntryPoints: []string{""}}}).compile()
+	require.ErrorContains(t, err, "empty entryPoints pattern")
+}
+
+func TestFilter_allow(t *testing.T) {
+	filter, err := (&Filter{
+		Include: &FilterRules{
+			Providers: []string{"@docker", "file"},
+			Hosts:     []string{"*.example.com"},
+		},
+		Exclude: &FilterRules{
+			Routers:     []string{"re:^(admin|dashboard)"},
+			EntryPoints: []string{"lan*"},
+		},
+	}).compile()
+	require.NoError(t, err)
+
+	cases := []struct {
+		item   candidate
+		allow  bool
+		reason string
+	}{
+		{candidate{key: "app@docker", entryPoints: []string{"web"}, hosts: []string{"app.example.com"}}, true, ""},
+		{candidate{key: "app@file", hosts: []string{"app.example.com"}}, true, ""},
+		{candidate{key: "app@kubernetes", hosts: []string{"app.example.com"}}, false, "provider is not included"},
+		{candidate{key: "app@docker", hosts: []string{"app.example.org"}}, false, "host is not included"},
+		{candidate{key: "app@docker"}, false, "host is not included"},
+		{candidate{key: "admin-ui@docker", hosts: []string{"admin.example.com"}}, false, "router is excluded"},
+		{
+			candidate{key: "app@docker", entryPoints: []string{"web", "lan"}, hosts: []string{"app.example.com"}},
+			false,
+			"entrypoint is excluded",
+		},
+	}
+
+	for _, item := range cases {
+		allow, reason := filter.allow(item.item)
+		require.Equal(t, item.allow, allow, item.item.key)
+		require.Equal(t, item.reason, reason, item.item.key)
+	}
+
+	var none *routerFilter
+	allow, _ := none.allow(candidate{key: "app@docker"})
+	require.True(t, allow)
+}
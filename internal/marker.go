@@ -0,0 +1,62 @@
+package internal
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+func validateMarker(marker string) error {
+	name, provider, namespaced := strings.Cut(marker, "@")
+	if marker != "" && (name == "" || namespaced && (provider == "" || strings.Contains(provider, "@"))) {
+		return fmt.Errorf("expected name or name@provider, got %q", marker)
+	}
+
+	return nil
+}
+
+// isMarker reports whether the middleware reference of a router points to the marker.
+// A marker without provider matches the middleware of any provider.
+func isMarker(marker, router, ref string) bool {
+	key := serviceKey(router, ref)
+	if strings.Contains(marker, "@") {
+		return key == marker
+	}
+
+	name, _, _ := strings.Cut(key, "@")
+
+	return name == marker
+}
+
+// optedIn reports whether the router references the export marker and removes
+// the marker from its middlewares. Without a marker every router is opted in.
+func (c *Client) optedIn(kind, key string, middlewares *[]string) bool {
+	if c.marker == "" {
+		return true
+	}
+
+	var (
+		found bool
+		rest  []string
+	)
+
+	for _, ref := range *middlewares {
+		if isMarker(c.marker, key, ref) {
+			found = true
+
+			continue
+		}
+
+		rest = append(rest, ref)
+	}
+
+	if !found {
+		log.Printf("skip %s %q(client:%q): no export marker %q", kind, key, c.endpoint.Host, c.marker)
+
+		return false
+	}
+
+	*middlewares = rest
+
+	return true
+}
@@ -0,0 +1,58 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/genconf/dynamic"
+)
+
+func TestValidateMarker(t *testing.T) {
+	require.NoError(t, validateMarker(""))
+	require.NoError(t, validateMarker("export-central"))
+	require.NoError(t, validateMarker("export-central@file"))
+	require.Error(t, validateMarker("@file"))
+	require.Error(t, validateMarker("export-central@"))
+	require.Error(t, validateMarker("export@central@file"))
+}
+
+func TestIsMarker(t *testing.T) {
+	require.True(t, isMarker("export-central@file", "app@docker", "export-central@file"))
+	require.False(t, isMarker("export-central@file", "app@docker", "export-central"))
+	require.True(t, isMarker("export-central@file", "app@file", "export-central"))
+	require.True(t, isMarker("export-central", "app@docker", "export-central@file"))
+	require.False(t, isMarker("export-central", "app@docker", "auth@file"))
+}
+
+func TestClient_marker(t *testing.T) {
+	res := &rawData{
+		Routers: map[string]*rawRouter{
+			"app@docker": {Router: dynamic.Router{
+				Service:     "backend",
+				Rule:        "Host(`app.example.com`)",
+				Middlewares: []string{"auth@file", "export-central@file"},
+			}},
+			"admin@docker": {Router: dynamic.Router{
+				Service:     "backend",
+				Rule:        "Host(`admin.example.com`)",
+				Middlewares: []string{"auth@file"},
+			}},
+		},
+		Services: map[string]*rawService{
+			"backend@docker": {Service: dynamic.Service{LoadBalancer: &dynamic.ServersLoadBalancer{
+				Servers: []dynamic.Server{{URL: "http://10.0.0.1:80"}},
+			}}},
+		},
+	}
+
+	cli := &Client{endpoint: Endpoint{Host: "worker", WEB: 80}, marker: "export-central@file"}
+	cfg := cli.prepareResponse(res)
+	require.NotNil(t, cfg.HTTP)
+	require.Len(t, cfg.HTTP.Routers, 1)
+	require.Contains(t, cfg.HTTP.Routers, "app-docker-worker")
+	require.Equal(t, []string{"auth@file"}, res.Routers["app@docker"].Middlewares)
+
+	cli.marker = ""
+	cfg = cli.prepareResponse(res)
+	require.Len(t, cfg.HTTP.Routers, 2)
+}
@@ -0,0 +1,94 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/traefik/genconf/dynamic"
+)
+
+// copyMiddlewares copies the middlewares of a router into output under
+// endpoint-scoped names and returns the rewritten references.
+func (c *Client) copyMiddlewares(
+	res *rawData,
+	output *dynamic.HTTPConfiguration,
+	router string,
+	refs []string,
+) []string {
+	var out []string
+	for _, ref := range refs {
+		name, err := c.copyMiddleware(res, output, router, ref, nil)
+		if err != nil {
+			log.Printf("skip middleware %q of router %q(client:%q): %s", ref, router, c.endpoint.Host, err)
+
+			continue
+		}
+
+		out = append(out, name)
+	}
+
+	return out
+}
+
+func (c *Client) copyMiddleware(
+	res *rawData,
+	output *dynamic.HTTPConfiguration,
+	owner, ref string,
+	seen []string,
+) (string, error) {
+	key := serviceKey(owner, ref)
+	if strings.HasSuffix(key, "@internal") {
+		return "", errors.New("internal middlewares are not copied")
+	} else if slices.Contains(seen, key) {
+		return "", fmt.Errorf("chain loop through %q", key)
+	}
+
+	item, ok := res.Middlewares[key]
+	switch {
+	case !ok:
+		return "", fmt.Errorf("middleware %q not found", key)
+	case item.disabled():
+		return "", fmt.Errorf("middleware %q is disabled: %s", key, item.errors())
+	case len(item.Plugin) > 0:
+		return "", fmt.Errorf("plugin middleware %q is not supported", key)
+	case item.Errors != nil:
+		return "", fmt.Errorf("middleware %q references remote service %q", key, item.Errors.Service)
+	case rewritesPath(&item.Middleware):
+		return "", fmt.Errorf("middleware %q rewrites the path the remote router matches on", key)
+	}
+
+	uniq := c.scopedName(key)
+	if _, ok = output.Middlewares[uniq]; ok {
+		return uniq, nil
+	}
+
+	middleware := item.Middleware
+	if middleware.Chain != nil {
+		// references inside a chain are resolved against the chain's provider
+		chain := make([]string, 0, len(middleware.Chain.Middlewares))
+		for _, sub := range middleware.Chain.Middlewares {
+			val, err := c.copyMiddleware(res, output, key, sub, append(seen, key))
+			if err != nil {
+				return "", fmt.Errorf("chain %q: %w", key, err)
+			}
+
+			chain = append(chain, val)
+		}
+
+		middleware.Chain = &dynamic.Chain{Middlewares: chain}
+	}
+
+	output.Middlewares[uniq] = &middleware
+
+	return uniq, nil
+}
+
+// rewritesPath reports whether a middleware changes the request path. The request
+// is still routed by the remote instance, whose rule expects the original path.
+func rewritesPath(m *dynamic.Middleware) bool {
+	return m.StripPrefix != nil || m.StripPrefixRegex != nil ||
+		m.ReplacePath != nil || m.ReplacePathRegex != nil || m.AddPrefix != nil
+}
@@ -0,0 +1,149 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/genconf/dynamic"
+)
+
+func TestClient_copyMiddlewares(t *testing.T) {
+	res := &rawData{
+		Routers: map[string]*rawRouter{
+			"app@docker": {Router: dynamic.Router{
+				Service: "backend",
+				Rule:    "Host(`app.example.com`)",
+				Middlewares: []string{
+					"allow",
+					"secured@file",
+					"strip",
+					"auth@plugin",
+					"oops@file",
+					"missing",
+					"dashboard@internal",
+					"loop@file",
+				},
+			}},
+		},
+		Services: map[string]*rawService{
+			"backend@docker": {Service: dynamic.Service{LoadBalancer: &dynamic.ServersLoadBalancer{
+				Servers: []dynamic.Server{{URL: "http://10.0.0.1:80"}},
+			}}},
+		},
+		Middlewares: map[string]*rawMiddleware{
+			"allow@docker": {Middleware: dynamic.Middleware{
+				IPAllowList: &dynamic.IPAllowList{SourceRange: []string{"10.0.0.0/8"}},
+			}},
+			"strip@docker": {Middleware: dynamic.Middleware{
+				StripPrefix: &dynamic.StripPrefix{Prefixes: []string{"/app"}},
+			}},
+			"secured@file": {Middleware: dynamic.Middleware{
+				Chain: &dynamic.Chain{Middlewares: []string{"headers", "allow@docker"}},
+			}},
+			"headers@file": {Middleware: dynamic.Middleware{
+				Headers: &dynamic.Headers{FrameDeny: true},
+			}},
+			"auth@plugin": {Middleware: dynamic.Middleware{
+				Plugin: map[string]dynamic.PluginConf{"auth": {}},
+			}},
+			"oops@file": {Middleware: dynamic.Middleware{
+				Errors: &dynamic.ErrorPage{Service: "pages@file"},
+			}},
+			"loop@file": {Middleware: dynamic.Middleware{
+				Chain: &dynamic.Chain{Middlewares: []string{"loop"}},
+			}},
+		},
+	}
+
+	cli := &Client{endpoint: Endpoint{Host: "worker", WEB: 80}}
+	cfg := cli.prepareResponse(res)
+	require.Nil(t, cfg.HTTP.Routers["app-docker-worker"].Middlewares)
+	require.Empty(t, cfg.HTTP.Middlewares)
+
+	cli.copyMW = true
+	cfg = cli.prepareResponse(res)
+	require.Equal(t, []string{"allow-docker-worker", "secured-file-worker"},
+		cfg.HTTP.Routers["app-docker-worker"].Middlewares)
+	require.Equal(t, map[string]*dynamic.Middleware{
+		"allow-docker-worker": {IPAllowList: &dynamic.IPAllowList{SourceRange: []string{"10.0.0.0/8"}}},
+		"headers-file-worker": {Headers: &dynamic.Headers{FrameDeny: true}},
+		"secured-file-worker": {Chain: &dynamic.Chain{Middlewares: []string{"headers-file-worker", "allow-docker-worker"}}},
+	}, cfg.HTTP.Middlewares)
+	require.Equal(t, []string{"headers", "allow@docker"}, res.Middlewares["secured@file"].Chain.Middlewares)
+}
+
+func TestClient_copyMiddlewares_providers(t *testing.T) {
+	res := &rawData{
+		Routers: map[string]*rawRouter{
+			"app@docker": {Router: dynamic.Router{
+				Service:     "backend",
+				Rule:        "Host(`app`)",
+				Middlewares: []string{"auth"},
+			}},
+			"admin@docker": {Router: dynamic.Router{
+				Service:     "backend",
+				Rule:        "Host(`admin`)",
+				Middlewares: []string{"auth@file"},
+			}},
+		},
+		Services: map[string]*rawService{
+			"backend@docker": {Service: dynamic.Service{LoadBalancer: &dynamic.ServersLoadBalancer{}}},
+		},
+		Middlewares: map[string]*rawMiddleware{
+			"auth@docker": {Middleware: dynamic.Middleware{
+				IPAllowList: &dynamic.IPAllowList{SourceRange: []string{"10.0.0.0/8"}},
+			}},
+			"auth@file": {Middleware: dynamic.Middleware{
+				BasicAuth: &dynamic.BasicAuth{Users: []string{"admin:secret"}},
+			}},
+		},
+	}
+
+	cli := &Client{endpoint: Endpoint{Host: "worker", WEB: 80}, copyMW: true}
+	cfg := cli.prepareResponse(res)
+	require.Equal(t, []string{"auth-docker-worker"}, cfg.HTTP.Routers["app-docker-worker"].Middlewares)
+	require.Equal(t, []string{"auth-file-worker"}, cfg.HTTP.Routers["admin-docker-worker"].Middlewares)
+	require.NotNil(t, cfg.HTTP.Middlewares["auth-docker-worker"].IPAllowList)
+	require.NotNil(t, cfg.HTTP.Middlewares["auth-file-worker"].BasicAuth)
+}
+
+func TestClient_copyMiddlewares_redirect(t *testing.T) {
+	res := &rawData{
+		Routers: map[string]*rawRouter{
+			"app@docker": {Router: dynamic.Router{
+				Service:     "backend",
+				Rule:        "Host(`app.example.com`)",
+				Middlewares: []string{"auth"},
+			}},
+		},
+		Services: map[string]*rawService{
+			"backend@docker": {Service: dynamic.Service{LoadBalancer: &dynamic.ServersLoadBalancer{}}},
+		},
+		Middlewares: map[string]*rawMiddleware{
+			"auth@docker": {Middleware: dynamic.Middleware{
+				BasicAuth: &dynamic.BasicAuth{Users: []string{"admin:secret"}},
+			}},
+		},
+	}
+
+	resolver := "letsencrypt"
+	cli := &Client{endpoint: Endpoint{Host: "worker", WEB: 80}, resolver: &resolver, copyMW: true}
+	cfg := cli.prepareResponse(res)
+	require.Equal(t, []string{"http2https"}, cfg.HTTP.Routers["app-docker-worker"].Middlewares)
+	require.Equal(t, []string{"auth-docker-worker"}, cfg.HTTP.Routers["app-docker-worker-secure"].Middlewares)
+
+	// without redirect the plain router serves the requests, so it keeps the copied middlewares
+	cli.redirect = &Redirect{Disabled: true}
+	cfg = cli.prepareResponse(res)
+	require.Equal(t, []string{"auth-docker-worker"}, cfg.HTTP.Routers["app-docker-worker"].Middlewares)
+	require.Equal(t, []string{"auth-docker-worker"}, cfg.HTTP.Routers["app-docker-worker-secure"].Middlewares)
+}
+
+func TestRewritesPath(t *testing.T) {
+	require.False(t, rewritesPath(&dynamic.Middleware{Headers: &dynamic.Headers{}}))
+	require.True(t, rewritesPath(&dynamic.Middleware{StripPrefix: &dynamic.StripPrefix{}}))
+	require.True(t, rewritesPath(&dynamic.Middleware{StripPrefixRegex: &dynamic.StripPrefixRegex{}}))
+	require.True(t, rewritesPath(&dynamic.Middleware{ReplacePath: &dynamic.ReplacePath{}}))
+	require.True(t, rewritesPath(&dynamic.Middleware{ReplacePathRegex: &dynamic.ReplacePathRegex{}}))
+	require.True(t, rewritesPath(&dynamic.Middleware{AddPrefix: &dynamic.AddPrefix{}}))
+}
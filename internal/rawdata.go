@@ -0,0 +1,199 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/traefik/genconf/dynamic"
+)
+
+const (
+	statusDisabled = "disabled"
+	statusWarning  = "warning"
+	statusUp       = "UP"
+
+	// ruleSyntaxV2 marks routers whose rule is parsed with the Traefik v2 syntax.
+	ruleSyntaxV2 = "v2"
+)
+
+// rawState holds the runtime fields Traefik adds to every rawdata entry.
+type rawState struct {
+	Status string   `json:"status,omitempty"`
+	Err    []string `json:"error,omitempty"`
+	UsedBy []string `json:"usedBy,omitempty"`
+	Using  []string `json:"using,omitempty"`
+}
+
+func (s rawState) disabled() bool { return s.Status == statusDisabled }
+
+func (s rawState) errors() string { return strings.Join(s.Err, "; ") }
+
+type rawRouter struct {
+	dynamic.Router
+	rawState
+
+	RuleSyntax string `json:"ruleSyntax,omitempty"`
+}
+
+type rawService struct {
+	dynamic.Service
+	rawState
+
+	ServerStatus map[string]string `json:"serverStatus,omitempty"`
+}
+
+// upServers returns the number of servers the remote reports UP. Without
+// a serverStatus (e.g. no load balancer), every configured server is counted.
+func (s *rawService) upServers() int {
+	if len(s.ServerStatus) == 0 {
+		if s.LoadBalancer == nil {
+			return 0
+		}
+
+		return len(s.LoadBalancer.Servers)
+	}
+
+	var out int
+	for _, status := range s.ServerStatus {
+		if status == statusUp {
+			out++
+		}
+	}
+
+	return out
+}
+
+type rawMiddleware struct {
+	dynamic.Middleware
+	rawState
+}
+
+type rawTCPRouter struct {
+	dynamic.TCPRouter
+	rawState
+}
+
+type rawTCPService struct {
+	dynamic.TCPService
+	rawState
+
+	ServerStatus map[string]string `json:"serverStatus,omitempty"`
+}
+
+type rawUDPRouter struct {
+	dynamic.UDPRouter
+	rawState
+}
+
+type rawUDPService struct {
+	dynamic.UDPService
+	rawState
+
+	ServerStatus map[string]string `json:"serverStatus,omitempty"`
+}
+
+// rawData is the payload of Traefik's /api/rawdata.
+// Entries that could not be decoded are collected into malformed,
+// so a single broken item does not discard the whole response.
+type rawData struct {
+	Routers     map[string]*rawRouter
+	Services    map[string]*rawService
+	Middlewares map[string]*rawMiddleware
+	TCPRouters  map[string]*rawTCPRouter
+	TCPServices map[string]*rawTCPService
+	UDPRouters  map[string]*rawUDPRouter
+	UDPServices map[string]*rawUDPService
+
+	malformed []error
+}
+
+type rawSections struct {
+	Routers     map[string]json.RawMessage `json:"routers"`
+	Services    map[string]json.RawMessage `json:"services"`
+	Middlewares map[string]json.RawMessage `json:"middlewares"`
+	TCPRouters  map[string]json.RawMessage `json:"tcpRouters"`
+	TCPServices map[string]json.RawMessage `json:"tcpServices"`
+	UDPRouters  map[string]json.RawMessage `json:"udpRouters"`
+	UDPServices map[string]json.RawMessage `json:"udpServices"`
+}
+
+func (r *rawData) UnmarshalJSON(data []byte) error {
+	var sections rawSections
+	if err := json.Unmarshal(data, &sections); err != nil {
+		return err
+	}
+
+	r.Routers = make(map[string]*rawRouter, len(sections.Routers))
+	r.decode("routers", sections.Routers, func(key string, msg json.RawMessage) error {
+		item := new(rawRouter)
+		r.Routers[key] = item
+
+		return json.Unmarshal(msg, item)
+	}, func(key string) { delete(r.Routers, key) })
+
+	r.Services = make(map[string]*rawService, len(sections.Services))
+	r.decode("services", sections.Services, func(key string, msg json.RawMessage) error {
+		item := new(rawService)
+		r.Services[key] = item
+
+		return json.Unmarshal(msg, item)
+	}, func(key string) { delete(r.Services, key) })
+
+	r.Middlewares = make(map[string]*rawMiddleware, len(sections.Middlewares))
+	r.decode("middlewares", sections.Middlewares, func(key string, msg json.RawMessage) error {
+		item := new(rawMiddleware)
+		r.Middlewares[key] = item
+
+		return json.Unmarshal(msg, item)
+	}, func(key string) { delete(r.Middlewares, key) })
+
+	r.TCPRouters = make(map[string]*rawTCPRouter, len(sections.TCPRouters))
+	r.decode("tcpRouters", sections.TCPRouters, func(key string, msg json.RawMessage) error {
+		item := new(rawTCPRouter)
+		r.TCPRouters[key] = item
+
+		return json.Unmarshal(msg, item)
+	}, func(key string) { delete(r.TCPRouters, key) })
+
+	r.TCPServices = make(map[string]*rawTCPService, len(sections.TCPServices))
+	r.decode("tcpServices", sections.TCPServices, func(key string, msg json.RawMessage) error {
+		item := new(rawTCPService)
+		r.TCPServices[key] = item
+
+		return json.Unmarshal(msg, item)
+	}, func(key string) { delete(r.TCPServices, key) })
+
+	r.UDPRouters = make(map[string]*rawUDPRouter, len(sections.UDPRouters))
+	r.decode("udpRouters", sections.UDPRouters, func(key string, msg json.RawMessage) error {
+		item := new(rawUDPRouter)
+		r.UDPRouters[key] = item
+
+		return json.Unmarshal(msg, item)
+	}, func(key string) { delete(r.UDPRouters, key) })
+
+	r.UDPServices = make(map[string]*rawUDPService, len(sections.UDPServices))
+	r.decode("udpServices", sections.UDPServices, func(key string, msg json.RawMessage) error {
+		item := new(rawUDPService)
+		r.UDPServices[key] = item
+
+		return json.Unmarshal(msg, item)
+	}, func(key string) { delete(r.UDPServices, key) })
+
+	return nil
+}
+
+func (r *rawData) decode(
+	section string,
+	items map[string]json.RawMessage,
+	store func(string, json.RawMessage) error,
+	drop func(string),
+) {
+	for key, msg := range items {
+		if err := store(key, msg); err != nil {
+			drop(key)
+
+			r.malformed = append(r.malformed, fmt.Errorf("could not decode %s %q: %w", section, key, err))
+		}
+	}
+}
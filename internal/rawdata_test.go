@@ -0,0 +1,62 @@
+package internal
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRawData(t *testing.T) {
+	data, err := os.ReadFile("../fixtures/jaeger-api-rawdata.json")
+	require.NoError(t, err)
+
+	var raw rawData
+	require.NoError(t, json.Unmarshal(data, &raw))
+	require.Empty(t, raw.malformed)
+
+	require.Contains(t, raw.Routers, "whoami@docker")
+	require.Equal(t, "whoami", raw.Routers["whoami@docker"].Service)
+	require.Equal(t, 21, raw.Routers["whoami@docker"].Priority)
+	require.Equal(t, "enabled", raw.Routers["whoami@docker"].Status)
+	require.Equal(t, []string{"web"}, raw.Routers["whoami@docker"].Using)
+	require.Equal(t, "default", raw.Routers["api@internal"].RuleSyntax)
+
+	require.Contains(t, raw.Services, "whoami@docker")
+	require.Equal(t, []string{"whoami@docker"}, raw.Services["whoami@docker"].UsedBy)
+	require.Equal(t, map[string]string{"http://192.168.97.2:80": "UP"}, raw.Services["whoami@docker"].ServerStatus)
+	require.Equal(t, 1, raw.Services["whoami@docker"].upServers())
+	require.Len(t, raw.Middlewares, 2)
+}
+
+const malformedResponse = `{
+	"routers": {
+		"broken@docker": {"rule": 42},
+		"valid@docker": {"rule": "Host(` + "`valid.example.com`" + `)", "service": "valid"},
+		"disabled@docker": {
+			"rule": "Host(` + "`disabled.example.com`" + `)",
+			"service": "valid",
+			"status": "disabled",
+			"error": ["middleware \"missing@docker\" does not exist"]
+		}
+	},
+	"services": {
+		"valid@docker": {"loadBalancer": {"servers": [{"url": "http://10.0.0.1:80"}]}, "unknown": true}
+	}
+}`
+
+func TestRawData_malformed(t *testing.T) {
+	var raw rawData
+	require.NoError(t, json.Unmarshal([]byte(malformedResponse), &raw))
+
+	require.Len(t, raw.malformed, 1)
+	require.ErrorContains(t, raw.malformed[0], `could not decode routers "broken@docker"`)
+	require.NotContains(t, raw.Routers, "broken@docker")
+	require.Contains(t, raw.Routers, "valid@docker")
+
+	require.True(t, raw.Routers["disabled@docker"].disabled())
+	require.Equal(t, `middleware "missing@docker" does not exist`, raw.Routers["disabled@docker"].errors())
+
+	require.Error(t, json.Unmarshal([]byte(`[]`), &raw))
+}
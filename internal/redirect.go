@@ -0,0 +1,82 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/traefik/genconf/dynamic"
+)
+
+const defaultRedirectName = "http2https"
+
+// Redirect controls how plain routers send clients to their secure twin.
+type Redirect struct {
+	Disabled    bool     `json:"disabled"    yaml:"disabled"    toml:"disabled"    mapstructure:"disabled"`
+	Name        string   `json:"name"        yaml:"name"        toml:"name"        mapstructure:"name"`
+	Permanent   *bool    `json:"permanent"   yaml:"permanent"   toml:"permanent"   mapstructure:"permanent"`
+	Port        string   `json:"port"        yaml:"port"        toml:"port"        mapstructure:"port"`
+	SecureOnly  bool     `json:"secureOnly"  yaml:"secureOnly"  toml:"secureOnly"  mapstructure:"secureOnly"`
+	EntryPoints []string `json:"entryPoints" yaml:"entryPoints" toml:"entryPoints" mapstructure:"entryPoints"`
+}
+
+func (r *Redirect) validate() error {
+	if r == nil {
+		return nil
+	}
+
+	if strings.Contains(r.Name, "@") {
+		return fmt.Errorf("expected middleware name without provider, got %q", r.Name)
+	}
+
+	if port, err := strconv.Atoi(r.Port); r.Port != "" && (err != nil || port <= 0 || port > 65535) {
+		return fmt.Errorf("wrong port: %q", r.Port)
+	}
+
+	if slices.Contains(r.EntryPoints, "") {
+		return errors.New("empty entrypoint")
+	}
+
+	return nil
+}
+
+// secureOnly reports whether secure routers replace the plain ones.
+func (r *Redirect) secureOnly() bool {
+	return r != nil && r.SecureOnly
+}
+
+// entryPoints returns the central entrypoints of secure routers,
+// or nil when they are bound as the plain ones.
+func (r *Redirect) entryPoints() []string {
+	if r == nil {
+		return nil
+	}
+
+	return r.EntryPoints
+}
+
+// middleware returns the redirect middleware attached to plain routers
+// and its name, or nil when plain routers are not redirected.
+func (r *Redirect) middleware() (string, *dynamic.Middleware) {
+	out := &dynamic.RedirectScheme{Scheme: "https", Permanent: true}
+	if r == nil {
+		return defaultRedirectName, &dynamic.Middleware{RedirectScheme: out}
+	} else if r.Disabled {
+		return "", nil
+	}
+
+	name := r.Name
+	if name == "" {
+		name = defaultRedirectName
+	}
+
+	if r.Permanent != nil {
+		out.Permanent = *r.Permanent
+	}
+
+	out.Port = r.Port
+
+	return name, &dynamic.Middleware{RedirectScheme: out}
+}
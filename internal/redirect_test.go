@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/genconf/dynamic"
+)
+
+func TestRedirect_validate(t *testing.T) {
+	var redirect *Redirect
+	require.NoError(t, redirect.validate())
+
+	redirect = &Redirect{Name: "to-https", Port: "8443", EntryPoints: []string{"websecure"}}
+	require.NoError(t, redirect.validate())
+
+	redirect.Name = "to-https@file"
+	require.ErrorContains(t, redirect.validate(), "without provider")
+
+	redirect.Name = ""
+	for _, port := range []string{"https", "0", "65536"} {
+		redirect.Port = port
+		require.ErrorContains(t, redirect.validate(), "wrong port")
+	}
+
+	redirect.Port = ""
+	redirect.EntryPoints = []string{""}
+	require.ErrorContains(t, redirect.validate(), "empty entrypoint")
+}
+
+func TestClient_redirect(t *testing.T) {
+	res := &rawData{
+		Routers: map[string]*rawRouter{
+			"app@docker": {Router: dynamic.Router{Service: "backend", Rule: "Host(`app.example.com`)"}},
+		},
+		Services: map[string]*rawService{
+			"backend@docker": {Service: dynamic.Service{LoadBalancer: &dynamic.ServersLoadBalancer{}}},
+		},
+	}
+
+	resolver := "letsencrypt"
+	cli := &Client{endpoint: Endpoint{Host: "worker", WEB: 80}, resolver: &resolver}
+
+	permanent := false
+	cli.redirect = &Redirect{Name: "to-https", Permanent: &permanent, Port: "8443"}
+	cfg := cli.prepareResponse(res)
+	require.Equal(t, []string{"to-https"}, cfg.HTTP.Routers["app-docker-worker"].Middlewares)
+	require.Equal(t, map[string]*dynamic.Middleware{
+		"to-https": {RedirectScheme: &dynamic.RedirectScheme{Scheme: "https", Port: "8443"}},
+	}, cfg.HTTP.Middlewares)
+
+	cli.redirect = &Redirect{Disabled: true}
+	cfg = cli.prepareResponse(res)
+	require.Len(t, cfg.HTTP.Routers, 2)
+	require.Empty(t, cfg.HTTP.Routers["app-docker-worker"].Middlewares)
+	require.Empty(t, cfg.HTTP.Middlewares)
+
+	cli.redirect = &Redirect{SecureOnly: true, EntryPoints: []string{"websecure"}}
+	cfg = cli.prepareResponse(res)
+	require.Len(t, cfg.HTTP.Routers, 1)
+	require.Equal(t, []string{"websecure"}, cfg.HTTP.Routers["app-docker-worker-secure"].EntryPoints)
+	require.Empty(t, cfg.HTTP.Middlewares)
+
+	// routers without TLS settings keep their plain router
+	cli.resolver = nil
+	cfg = cli.prepareResponse(res)
+	require.Len(t, cfg.HTTP.Routers, 1)
+	require.Contains(t, cfg.HTTP.Routers, "app-docker-worker")
+}
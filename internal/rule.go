@@ -0,0 +1,168 @@
+package internal
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const (
+	matcherHost       = "Host"
+	matcherHostSNI    = "HostSNI"
+	matcherHostRegexp = "HostRegexp"
+)
+
+var (
+	ruleMatcher = regexp.MustCompile("\\b(Host|HostHeader|HostSNI|HostRegexp|HostSNIRegexp)\\s*\\(([^)]*)\\)")
+	ruleValue   = regexp.MustCompile("`([^`]*)`|\"([^\"]*)\"")
+)
+
+// ruleMatchers extracts the values of host matchers from a Traefik rule,
+// grouped by matcher name (HostHeader is reported as Host).
+func ruleMatchers(rule string) map[string][]string {
+	out := make(map[string][]string)
+	for _, match := range ruleMatcher.FindAllStringSubmatch(rule, -1) {
+		name := match[1]
+		switch name {
+		case "HostHeader":
+			name = matcherHost
+		case "HostSNIRegexp":
+			name = matcherHostRegexp
+		}
+
+		for _, value := range ruleValue.FindAllStringSubmatch(match[2], -1) {
+			val := value[1] + value[2]
+			if name != matcherHostRegexp {
+				val = strings.ToLower(val)
+			}
+
+			out[name] = append(out[name], val)
+		}
+	}
+
+	return out
+}
+
+// RuleHosts returns the literal hostnames of Host and HostSNI matchers, without duplicates.
+func RuleHosts(rule string) []string {
+	matchers := ruleMatchers(rule)
+
+	var out []string
+	for _, host := range append(matchers[matcherHost], matchers[matcherHostSNI]...) {
+		if host != "*" && !slices.Contains(out, host) {
+			out = append(out, host)
+		}
+	}
+
+	return out
+}
+
+// ruleParser walks the boolean structure of a Traefik rule.
+type ruleParser struct {
+	rule string
+	pos  int
+}
+
+// hostBound reports whether every request matched by the rule is also matched by
+// a host matcher, e.g. "Host(`a`) || PathPrefix(`/`)" is not bound to a host.
+// Catch-all HostSNI(`*`) and negated matchers do not bind a rule.
+func hostBound(rule string) bool {
+	parser := &ruleParser{rule: rule}
+
+	bound, ok := parser.expr()
+	if parser.skipSpaces(); !ok || parser.pos != len(rule) {
+		return false
+	}
+
+	return bound
+}
+
+func (p *ruleParser) skipSpaces() {
+	for p.pos < len(p.rule) && strings.ContainsRune(" \t\r\n", rune(p.rule[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *ruleParser) consume(token string) bool {
+	if p.skipSpaces(); strings.HasPrefix(p.rule[p.pos:], token) {
+		p.pos += len(token)
+
+		return true
+	}
+
+	return false
+}
+
+// expr := term { "||" term }, bound when every term is.
+func (p *ruleParser) expr() (bool, bool) {
+	bound, ok := p.term()
+	for ok && p.consume("||") {
+		var next bool
+		next, ok = p.term()
+		bound = bound && next
+	}
+
+	return bound, ok
+}
+
+// term := factor { "&&" factor }, bound when any factor is.
+func (p *ruleParser) term() (bool, bool) {
+	bound, ok := p.factor()
+	for ok && p.consume("&&") {
+		var next bool
+		next, ok = p.factor()
+		bound = bound || next
+	}
+
+	return bound, ok
+}
+
+// factor := "!" factor | "(" expr ")" | matcher.
+func (p *ruleParser) factor() (bool, bool) {
+	switch {
+	case p.consume("!"):
+		_, ok := p.factor()
+
+		return false, ok
+	case p.consume("("):
+		bound, ok := p.expr()
+
+		return bound, ok && p.consume(")")
+	}
+
+	return p.matcher()
+}
+
+func (p *ruleParser) matcher() (bool, bool) {
+	start := p.pos
+	for p.pos < len(p.rule) && p.rule[p.pos] != '(' {
+		p.pos++
+	}
+
+	name := strings.TrimSpace(p.rule[start:p.pos])
+	if name == "" || p.pos == len(p.rule) {
+		return false, false
+	}
+
+	// arguments are quoted, so parentheses inside them are skipped
+	var quote byte
+	for p.pos++; p.pos < len(p.rule); p.pos++ {
+		switch char := p.rule[p.pos]; {
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			}
+		case char == '`' || char == '"':
+			quote = char
+		case char == ')':
+			p.pos++
+
+			values := ruleMatchers(p.rule[start:p.pos])
+
+			return len(values[matcherHost]) > 0 || len(values[matcherHostRegexp]) > 0 ||
+				slices.ContainsFunc(values[matcherHostSNI], func(val string) bool { return val != "*" }), true
+		}
+	}
+
+	return false, false
+}
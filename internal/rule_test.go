@@ -0,0 +1,52 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuleMatchers(t *testing.T) {
+	require.Equal(t, map[string][]string{
+		"Host":       {"a.example.com", "b.example.com"},
+		"HostRegexp": {"^.+\\.lab\\.example\\.com$"},
+	}, ruleMatchers("(Host(`A.example.com`) || HostHeader(\"b.example.com\")) && "+
+		"PathPrefix(`/api`) || HostRegexp(`^.+\\.lab\\.example\\.com$`)"))
+
+	require.Equal(t, map[string][]string{"HostSNI": {"db.example.com"}}, ruleMatchers("HostSNI(`db.example.com`)"))
+	require.Empty(t, ruleMatchers("PathPrefix(`/`)"))
+	require.Empty(t, ruleMatchers("VirtualHost(`a`)"))
+}
+
+func TestRuleHosts(t *testing.T) {
+	require.Equal(t, []string{"a.example.com", "b.example.com"},
+		RuleHosts("Host(`a.example.com`, `b.example.com`) || Host(`a.example.com`)"))
+	require.Equal(t, []string{"db.example.com"}, RuleHosts("HostSNI(`db.example.com`)"))
+	require.Empty(t, RuleHosts("HostSNI(`*`)"))
+	require.Empty(t, RuleHosts("PathPrefix(`/`)"))
+}
+
+func TestHostBound(t *testing.T) {
+	cases := map[string]bool{
+		"Host(`a`)":                                        true,
+		"Host(`a`) && PathPrefix(`/api`)":                  true,
+		"Host(`a`) || Host(`b`)":                           true,
+		"(Host(`a`) || Host(`b`)) && Method(`GET`)":        true,
+		"HostRegexp(`^.+\\.example\\.com$`)":               true,
+		"HostSNI(`db.example.com`)":                        true,
+		"PathPrefix(`/`) && (Host(`a`) || Host(`b`))":      true,
+		"Path(`/a(b)`) && Host(`a`)":                       true,
+		"HostSNI(`*`)":                                     false,
+		"PathPrefix(`/`)":                                  false,
+		"Host(`a`) || PathPrefix(`/`)":                     false,
+		"(Host(`a`) || PathPrefix(`/x`)) && Method(`GET`)": false,
+		"!Host(`a`)":                                       false,
+		"Host(`a`":                                         false,
+		"Host(`a`) &&":                                     false,
+		"":                                                 false,
+	}
+
+	for rule, bound := range cases {
+		require.Equal(t, bound, hostBound(rule), rule)
+	}
+}
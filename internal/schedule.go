@@ -0,0 +1,47 @@
+package internal
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Timeout returns the request timeout of the endpoint.
+func (c *Client) Timeout() time.Duration { return c.endpoint.Timeout }
+
+// MaxDelay returns the longest delay between two polls of the endpoint.
+func (c *Client) MaxDelay() time.Duration {
+	if c.endpoint.MaxBackoff > c.endpoint.PollInterval {
+		return c.endpoint.MaxBackoff
+	}
+
+	return c.endpoint.PollInterval
+}
+
+// NextPoll returns the delay before the next poll of the endpoint.
+// After consecutive failures it grows exponentially from the poll interval
+// up to maxBackoff, with jitter so endpoints do not retry in lockstep.
+func (c *Client) NextPoll(failures int) time.Duration {
+	return backoff(c.endpoint.PollInterval, c.endpoint.MaxBackoff, failures)
+}
+
+func backoff(interval, limit time.Duration, failures int) time.Duration {
+	if failures <= 0 || limit <= interval {
+		return interval
+	}
+
+	delay := interval
+	for range failures {
+		if delay >= limit/2 {
+			delay = limit
+
+			break
+		}
+
+		delay *= 2
+	}
+
+	// equal jitter: keep at least half of the delay, randomize the rest
+	half := delay / 2
+
+	return half + time.Duration(rand.Int63n(int64(delay-half)+1)) // nolint:gosec
+}
@@ -0,0 +1,45 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackoff(t *testing.T) {
+	require.Equal(t, time.Second, backoff(time.Second, time.Minute, 0))
+	require.Equal(t, time.Second, backoff(time.Second, 0, 5))
+
+	for failures, limit := range []time.Duration{
+		time.Second,
+		2 * time.Second,
+		4 * time.Second,
+		8 * time.Second,
+		16 * time.Second,
+		time.Minute / 2,
+		time.Minute / 2,
+	} {
+		if failures == 0 {
+			continue
+		}
+
+		for range 100 {
+			delay := backoff(time.Second, time.Minute/2, failures)
+			require.GreaterOrEqual(t, delay, limit/2)
+			require.LessOrEqual(t, delay, limit)
+		}
+	}
+}
+
+func TestClient_schedule(t *testing.T) {
+	cli := &Client{endpoint: Endpoint{
+		PollInterval: time.Second,
+		Timeout:      time.Millisecond,
+		MaxBackoff:   time.Minute,
+	}}
+
+	require.Equal(t, time.Millisecond, cli.Timeout())
+	require.Equal(t, time.Second, cli.NextPoll(0))
+	require.Greater(t, cli.NextPoll(3), 3*time.Second)
+}
@@ -0,0 +1,87 @@
+package internal
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+const (
+	schemeHTTP  = "http"
+	schemeHTTPS = "https"
+)
+
+// EndpointTLS describes how to reach a remote Traefik API served over TLS.
+// CA, Cert and Key are paths to PEM files.
+type EndpointTLS struct {
+	CA                 string `json:"ca"                 yaml:"ca"                 toml:"ca"                 mapstructure:"ca"`
+	Cert               string `json:"cert"               yaml:"cert"               toml:"cert"               mapstructure:"cert"`
+	Key                string `json:"key"                yaml:"key"                toml:"key"                mapstructure:"key"`
+	ServerName         string `json:"serverName"         yaml:"serverName"         toml:"serverName"         mapstructure:"serverName"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify" yaml:"insecureSkipVerify" toml:"insecureSkipVerify" mapstructure:"insecureSkipVerify"`
+}
+
+func (t *EndpointTLS) Config() (*tls.Config, error) {
+	if t == nil {
+		return nil, nil
+	}
+
+	out := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         t.ServerName,
+		InsecureSkipVerify: t.InsecureSkipVerify, // nolint:gosec
+	}
+
+	if t.CA != "" {
+		data, err := os.ReadFile(t.CA)
+		if err != nil {
+			return nil, fmt.Errorf("could not read CA bundle: %w", err)
+		}
+
+		out.RootCAs = x509.NewCertPool()
+		if !out.RootCAs.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no PEM certificates found in CA bundle %q", t.CA)
+		}
+	}
+
+	switch {
+	case t.Cert == "" && t.Key == "":
+	case t.Cert == "" || t.Key == "":
+		return nil, errors.New("client certificate and key must be set together")
+	default:
+		cert, err := tls.LoadX509KeyPair(t.Cert, t.Key)
+		if err != nil {
+			return nil, fmt.Errorf("could not load client certificate %q/%q: %w", t.Cert, t.Key, err)
+		}
+
+		out.Certificates = []tls.Certificate{cert}
+	}
+
+	return out, nil
+}
+
+// httpClient returns the client used to reach the endpoint API.
+// Endpoints without TLS settings share the default one.
+func (e Endpoint) httpClient(shared *http.Client) (*http.Client, error) {
+	if e.TLS == nil {
+		return shared, nil
+	}
+
+	cfg, err := e.TLS.Config()
+	if err != nil {
+		return nil, err
+	}
+
+	transport, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return nil, errors.New("unexpected default transport")
+	}
+
+	transport = transport.Clone()
+	transport.TLSClientConfig = cfg
+
+	return &http.Client{Transport: transport}, nil
+}
@@ -0,0 +1,237 @@
+package internal
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/genconf/dynamic"
+)
+
+type testPKI struct {
+	folder     string
+	serverName string
+
+	ca     *x509.Certificate
+	caKey  *ecdsa.PrivateKey
+	caPath string
+
+	serverCert tls.Certificate
+	clientCert string
+	clientKey  string
+	otherKey   string
+	garbage    string
+}
+
+func writePEM(t *testing.T, path, kind string, data []byte) string {
+	t.Helper()
+
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: data}), 0o600))
+
+	return path
+}
+
+func issue(t *testing.T, pki *testPKI, tpl *x509.Certificate) ([]byte, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	parent, signer := tpl, key
+	if pki.ca != nil {
+		parent, signer = pki.ca, pki.caKey
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tpl, parent, &key.PublicKey, signer)
+	require.NoError(t, err)
+
+	return der, key
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	t.Helper()
+
+	pki := &testPKI{folder: t.TempDir(), serverName: "traefik.example.com"}
+
+	der, key := issue(t, pki, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	})
+
+	var err error
+	pki.ca, err = x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	pki.caKey = key
+	pki.caPath = writePEM(t, filepath.Join(pki.folder, "ca.pem"), "CERTIFICATE", der)
+
+	der, key = issue(t, pki, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: pki.serverName},
+		DNSNames:     []string{pki.serverName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+
+	pki.serverCert = tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+
+	der, key = issue(t, pki, &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "central"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+
+	raw, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	pki.clientCert = writePEM(t, filepath.Join(pki.folder, "client.pem"), "CERTIFICATE", der)
+	pki.clientKey = writePEM(t, filepath.Join(pki.folder, "client-key.pem"), "EC PRIVATE KEY", raw)
+
+	_, key = issue(t, pki, &x509.Certificate{SerialNumber: big.NewInt(4)})
+	raw, err = x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	pki.otherKey = writePEM(t, filepath.Join(pki.folder, "other-key.pem"), "EC PRIVATE KEY", raw)
+	pki.garbage = filepath.Join(pki.folder, "garbage.pem")
+	require.NoError(t, os.WriteFile(pki.garbage, []byte("not a certificate"), 0o600))
+
+	return pki
+}
+
+func TestEndpointTLS_Config(t *testing.T) {
+	pki := newTestPKI(t)
+
+	var empty *EndpointTLS
+	cfg, err := empty.Config()
+	require.NoError(t, err)
+	require.Nil(t, cfg)
+
+	_, err = (&EndpointTLS{CA: filepath.Join(pki.folder, "missing.pem")}).Config()
+	require.ErrorContains(t, err, "could not read CA bundle")
+
+	_, err = (&EndpointTLS{CA: pki.garbage}).Config()
+	require.ErrorContains(t, err, "no PEM certificates found in CA bundle")
+
+	_, err = (&EndpointTLS{Cert: pki.clientCert}).Config()
+	require.ErrorContains(t, err, "client certificate and key must be set together")
+
+	_, err = (&EndpointTLS{Cert: pki.clientCert, Key: pki.otherKey}).Config()
+	require.ErrorContains(t, err, "could not load client certificate")
+
+	cfg, err = (&EndpointTLS{
+		CA:         pki.caPath,
+		Cert:       pki.clientCert,
+		Key:        pki.clientKey,
+		ServerName: pki.serverName,
+	}).Config()
+	require.NoError(t, err)
+	require.Equal(t, pki.serverName, cfg.ServerName)
+	require.Len(t, cfg.Certificates, 1)
+	require.NotNil(t, cfg.RootCAs)
+}
+
+func TestEndpoint_validateTLS(t *testing.T) {
+	pki := newTestPKI(t)
+
+	endpoint := Endpoint{Host: "localhost", API: 1, WEB: 1, APIScheme: "ftp"}
+	require.ErrorContains(t, endpoint.validate(0), "wrong #0 endpoint apiScheme")
+
+	endpoint.APIScheme = schemeHTTP
+	endpoint.TLS = &EndpointTLS{CA: pki.caPath}
+	require.ErrorContains(t, endpoint.validate(0), "requires apiScheme")
+
+	endpoint.APIScheme = schemeHTTPS
+	endpoint.TLS = &EndpointTLS{CA: pki.garbage}
+	require.ErrorContains(t, endpoint.validate(0), "wrong #0 endpoint tls: no PEM certificates")
+
+	endpoint.TLS = &EndpointTLS{CA: pki.caPath}
+	require.NoError(t, endpoint.validate(0))
+}
+
+func TestClient_mutualTLS(t *testing.T) {
+	pki := newTestPKI(t)
+
+	data, err := os.ReadFile("../fixtures/jaeger-api-rawdata.json")
+	require.NoError(t, err)
+
+	web := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	api := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if assert.NotNil(t, r.TLS) {
+			assert.Len(t, r.TLS.PeerCertificates, 1)
+		}
+
+		w.WriteHeader(http.StatusOK)
+
+		assert.NoError(t, catchError(w.Write(data)))
+	}))
+
+	pool := x509.NewCertPool()
+	pool.AddCert(pki.ca)
+
+	api.TLS = &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{pki.serverCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+	}
+	api.StartTLS()
+
+	apiAddr, ok := api.Listener.Addr().(*net.TCPAddr)
+	require.True(t, ok)
+
+	webAddr, ok := web.Listener.Addr().(*net.TCPAddr)
+	require.True(t, ok)
+
+	cfg := Config{
+		ConnTimeout:  defaultTestConnTimeout,
+		PollInterval: defaultTestPollInterval,
+		Endpoints: []Endpoint{{
+			Host:      apiAddr.IP.String(),
+			API:       apiAddr.Port,
+			WEB:       webAddr.Port,
+			APIScheme: schemeHTTPS,
+			TLS:       &EndpointTLS{CA: pki.caPath, ServerName: pki.serverName},
+		}},
+	}
+
+	require.NoError(t, cfg.Validate())
+
+	_, err = cfg.PrepareClients(t.Context())
+	require.Error(t, err, "client certificate is required")
+
+	cfg.Endpoints[0].TLS.Cert = pki.clientCert
+	cfg.Endpoints[0].TLS.Key = pki.clientKey
+	require.NoError(t, cfg.Validate())
+
+	cli, err := cfg.PrepareClients(t.Context())
+	require.NoError(t, err)
+
+	out := make(chan *dynamic.Configuration, 1)
+	require.NoError(t, cli[0].FetchRaw(t.Context(), out))
+	require.NotNil(t, (<-out).HTTP)
+}
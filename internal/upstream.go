@@ -0,0 +1,57 @@
+package internal
+
+import (
+	"github.com/traefik/genconf/dynamic"
+	"github.com/traefik/genconf/dynamic/tls"
+)
+
+// secureUpstream reports whether a remote router is proxied to the secure web port:
+// a router with TLS would reject or redirect plain HTTP forwarded to the web port.
+func (c *Client) secureUpstream(router *rawRouter) bool {
+	return c.endpoint.WebSecure > 0 && router.TLS != nil
+}
+
+// balancer returns the load balancer of a generated service. Routers with TLS on
+// the remote instance get a servers transport presenting the host of their rule,
+// so the remote instance selects the matching certificate.
+func (c *Client) balancer(
+	output *dynamic.HTTPConfiguration,
+	uniq string,
+	router *rawRouter,
+) *dynamic.ServersLoadBalancer {
+	if !c.secureUpstream(router) {
+		return &dynamic.ServersLoadBalancer{Servers: []dynamic.Server{{URL: c.endpoint.webURL().String()}}}
+	}
+
+	transport := new(dynamic.ServersTransport)
+	if hosts := RuleHosts(router.Rule); len(hosts) > 0 {
+		transport.ServerName = hosts[0]
+	}
+
+	if cfg := c.endpoint.WebTLS; cfg != nil {
+		if cfg.ServerName != "" {
+			transport.ServerName = cfg.ServerName
+		}
+
+		if cfg.CA != "" {
+			transport.RootCAs = []string{cfg.CA}
+		}
+
+		if cfg.Cert != "" {
+			transport.Certificates = tls.Certificates{{CertFile: cfg.Cert, KeyFile: cfg.Key}}
+		}
+
+		transport.InsecureSkipVerify = cfg.InsecureSkipVerify
+	}
+
+	if output.ServersTransports == nil {
+		output.ServersTransports = make(map[string]*dynamic.ServersTransport)
+	}
+
+	output.ServersTransports[uniq] = transport
+
+	return &dynamic.ServersLoadBalancer{
+		Servers:          []dynamic.Server{{URL: c.endpoint.webSecureURL().String()}},
+		ServersTransport: uniq,
+	}
+}
@@ -0,0 +1,57 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/genconf/dynamic"
+	"github.com/traefik/genconf/dynamic/tls"
+)
+
+func TestClient_secureUpstream(t *testing.T) {
+	res := &rawData{
+		Routers: map[string]*rawRouter{
+			"app@docker": {Router: dynamic.Router{
+				Service: "backend",
+				Rule:    "Host(`app.example.com`)",
+				TLS:     &dynamic.RouterTLSConfig{},
+			}},
+			"web@docker": {Router: dynamic.Router{Service: "backend", Rule: "Host(`web.example.com`)"}},
+		},
+		Services: map[string]*rawService{
+			"backend@docker": {Service: dynamic.Service{LoadBalancer: &dynamic.ServersLoadBalancer{}}},
+		},
+	}
+
+	cli := &Client{endpoint: Endpoint{Host: "worker", WEB: 80}}
+	cfg := cli.prepareResponse(res)
+	require.Equal(t, "backend-docker-worker", cfg.HTTP.Routers["app-docker-worker"].Service)
+	require.Nil(t, cfg.HTTP.ServersTransports)
+
+	cli.endpoint.WebSecure = 443
+	cfg = cli.prepareResponse(res)
+	require.Equal(t, "app-docker-tls-worker", cfg.HTTP.Routers["app-docker-worker"].Service)
+	require.Equal(t, "backend-docker-worker", cfg.HTTP.Routers["web-docker-worker"].Service)
+	require.Equal(t, &dynamic.Service{LoadBalancer: &dynamic.ServersLoadBalancer{
+		Servers:          []dynamic.Server{{URL: "https://worker:443"}},
+		ServersTransport: "app-docker-tls-worker",
+	}}, cfg.HTTP.Services["app-docker-tls-worker"])
+	require.Equal(t, map[string]*dynamic.ServersTransport{
+		"app-docker-tls-worker": {ServerName: "app.example.com"},
+	}, cfg.HTTP.ServersTransports)
+
+	cli.weighted = true
+	cli.endpoint.WebTLS = &EndpointTLS{
+		CA:         "/etc/ca.pem",
+		Cert:       "/etc/cert.pem",
+		Key:        "/etc/key.pem",
+		ServerName: "worker.lan",
+	}
+	cfg = cli.prepareResponse(res)
+	require.Equal(t, "app-docker-tls-worker", cfg.HTTP.Services["app-docker-tls-worker-lb"].LoadBalancer.ServersTransport)
+	require.Equal(t, map[string]*dynamic.ServersTransport{"app-docker-tls-worker": {
+		ServerName:   "worker.lan",
+		RootCAs:      []string{"/etc/ca.pem"},
+		Certificates: tls.Certificates{{CertFile: "/etc/cert.pem", KeyFile: "/etc/key.pem"}},
+	}}, cfg.HTTP.ServersTransports)
+}
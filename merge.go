@@ -0,0 +1,246 @@
+package traefik_provider
+
+import (
+	"encoding/json"
+	"log"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/traefik/genconf/dynamic"
+
+	"github.com/im-kulikov/traefik-provider/internal"
+)
+
+const (
+	secureSuffix = "-secure"
+
+	// ownershipPolls is the number of polls an owner may miss
+	// before its hostnames are released by default.
+	ownershipPolls = 3
+)
+
+// merger combines the results of every endpoint into a single configuration.
+type merger struct {
+	aggregate bool
+	sticky    string
+	policy    string
+	weights   []int
+	owners    *ownership
+	conflicts []string
+}
+
+// member is a generated router taking part in an aggregated route.
+type member struct {
+	index  int
+	name   string
+	router *dynamic.Router
+}
+
+func newMerger(cfg *internal.Config, clients []*internal.Client) *merger {
+	out := &merger{
+		aggregate: cfg.Aggregate || cfg.ConflictPolicy == internal.ConflictMerge,
+		sticky:    cfg.StickyCookie,
+		policy:    cfg.ConflictPolicy,
+	}
+
+	if cfg.HostOwnership {
+		grace := cfg.OwnershipGrace
+		for _, client := range clients {
+			if delay := client.MaxDelay() * ownershipPolls; cfg.OwnershipGrace == 0 && delay > grace {
+				grace = delay
+			}
+		}
+
+		out.owners = newOwnership(grace)
+	}
+
+	for _, client := range clients {
+		out.weights = append(out.weights, client.Weight())
+	}
+
+	return out
+}
+
+// merge combines results (ordered as names) into a single configuration.
+func (m *merger) merge(names []string, results []*dynamic.Configuration) dynamic.Configuration {
+	var val dynamic.Configuration
+	for _, msg := range results {
+		if msg == nil {
+			continue
+		}
+
+		mergeHTTP(&val, msg.HTTP)
+		mergeTCP(&val, msg.TCP)
+		mergeUDP(&val, msg.UDP)
+	}
+
+	if m == nil {
+		return val
+	}
+
+	conflicts := conflictNames(names, results)
+	if m.owners != nil {
+		conflicts = append(conflicts, m.owners.apply(&val, names, results)...)
+	}
+
+	if m.aggregate && val.HTTP != nil {
+		m.aggregateHTTP(val.HTTP, names, results)
+	}
+
+	m.report(append(conflicts, m.resolveConflicts(&val, names, results)...))
+
+	return val
+}
+
+// trimHost strips the endpoint suffix from a generated name.
+func trimHost(name, host string) string {
+	if base, ok := strings.CutSuffix(name, "-"+host+secureSuffix); ok {
+		return base + secureSuffix
+	}
+
+	return strings.TrimSuffix(name, "-"+host)
+}
+
+// routeKey identifies routers of an endpoint that serve the same requests.
+// Middlewares are compared without the endpoint suffix, so copied chains
+// of the same name still match, while routers with other chains are kept apart.
+func routeKey(router *dynamic.Router, host string) string {
+	middlewares := make([]string, 0, len(router.Middlewares))
+	for _, name := range router.Middlewares {
+		middlewares = append(middlewares, trimHost(name, host))
+	}
+
+	data, _ := json.Marshal(struct {
+		Rule        string
+		EntryPoints []string
+		Middlewares []string
+		TLS         *dynamic.RouterTLSConfig
+	}{router.Rule, router.EntryPoints, middlewares, router.TLS})
+
+	return string(data)
+}
+
+// aggregateHTTP replaces routers with the same rule published by several
+// endpoints with a single router balancing between them.
+func (m *merger) aggregateHTTP(val *dynamic.HTTPConfiguration, names []string, results []*dynamic.Configuration) {
+	var order []string
+
+	groups := make(map[string][]member)
+	for i, msg := range results {
+		if msg == nil || msg.HTTP == nil {
+			continue
+		}
+
+		keys := make([]string, 0, len(msg.HTTP.Routers))
+		for name := range msg.HTTP.Routers {
+			keys = append(keys, name)
+		}
+
+		slices.Sort(keys)
+
+		for _, name := range keys {
+			// routers dropped by an earlier stage or shadowed by another endpoint are left out
+			if val.Routers[name] != msg.HTTP.Routers[name] {
+				continue
+			}
+
+			key := routeKey(msg.HTTP.Routers[name], names[i])
+			if _, ok := groups[key]; !ok {
+				order = append(order, key)
+			}
+
+			// one router per endpoint, the others are left as they are
+			if items := groups[key]; len(items) == 0 || items[len(items)-1].index != i {
+				groups[key] = append(items, member{index: i, name: name, router: msg.HTTP.Routers[name]})
+			}
+		}
+	}
+
+	for _, key := range order {
+		if items := groups[key]; len(items) > 1 {
+			m.combine(val, names, items)
+		}
+	}
+}
+
+func (m *merger) combine(val *dynamic.HTTPConfiguration, names []string, items []member) {
+	first := items[0]
+	name := trimHost(first.name, names[first.index])
+	uniq := trimHost(first.router.Service, names[first.index])
+
+	hosts := make([]string, 0, len(items))
+	for _, item := range items {
+		hosts = append(hosts, names[item.index])
+	}
+
+	service := m.balance(val, items)
+	if _, ok := val.Routers[name]; ok {
+		log.Printf("could not merge router %q of %q: name is taken", name, hosts)
+
+		return
+	} else if prev, ok := val.Services[uniq]; ok && !reflect.DeepEqual(prev, service) {
+		log.Printf("could not merge service %q of %q: name is taken", uniq, hosts)
+
+		return
+	}
+
+	router := *first.router
+	router.Service = uniq
+	for _, item := range items {
+		if item.router.Priority > router.Priority {
+			router.Priority = item.router.Priority
+		}
+
+		delete(val.Routers, item.name)
+	}
+
+	val.Routers[name] = &router
+	val.Services[uniq] = service
+}
+
+// balance returns a service spreading requests between the endpoints of items.
+// A plain load balancer is used while every endpoint has the same weight.
+func (m *merger) balance(val *dynamic.HTTPConfiguration, items []member) *dynamic.Service {
+	var sticky *dynamic.Sticky
+	if m.sticky != "" {
+		sticky = &dynamic.Sticky{Cookie: &dynamic.Cookie{Name: m.sticky}}
+	}
+
+	equal := true
+	balancer := &dynamic.ServersLoadBalancer{Sticky: sticky}
+	weighted := &dynamic.WeightedRoundRobin{Sticky: sticky}
+	for _, item := range items {
+		ref, weight := item.router.Service, m.weights[item.index]
+
+		// services weighted by the number of UP servers wrap a single balancer
+		service := val.Services[ref]
+		if service != nil && service.Weighted != nil && len(service.Weighted.Services) == 1 {
+			child := service.Weighted.Services[0]
+			if ref = child.Name; child.Weight != nil {
+				weight *= *child.Weight
+			}
+
+			service = val.Services[ref]
+		}
+
+		// servers of different transports can not share a load balancer
+		if service == nil || service.LoadBalancer == nil || service.LoadBalancer.ServersTransport != "" {
+			equal = false
+		} else {
+			balancer.Servers = append(balancer.Servers, service.LoadBalancer.Servers...)
+		}
+
+		if len(weighted.Services) > 0 && *weighted.Services[0].Weight != weight {
+			equal = false
+		}
+
+		weighted.Services = append(weighted.Services, dynamic.WRRService{Name: ref, Weight: &weight})
+	}
+
+	if equal {
+		return &dynamic.Service{LoadBalancer: balancer}
+	}
+
+	return &dynamic.Service{Weighted: weighted}
+}
@@ -0,0 +1,133 @@
+package traefik_provider
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/genconf/dynamic"
+)
+
+func endpointConfig(host, rule string) *dynamic.Configuration {
+	return &dynamic.Configuration{HTTP: &dynamic.HTTPConfiguration{
+		Routers: map[string]*dynamic.Router{
+			"api-" + host:             {Service: "api-" + host, Rule: rule, Priority: len(host)},
+			"api-" + host + "-secure": {Service: "api-" + host, Rule: rule, TLS: &dynamic.RouterTLSConfig{}},
+			"local-" + host:           {Service: "api-" + host, Rule: "Host(`" + host + "`)"},
+		},
+		Services: map[string]*dynamic.Service{
+			"api-" + host: {LoadBalancer: &dynamic.ServersLoadBalancer{
+				Servers: []dynamic.Server{{URL: "http://" + host + ":80"}},
+			}},
+		},
+	}}
+}
+
+func TestTrimHost(t *testing.T) {
+	require.Equal(t, "api", trimHost("api-worker", "worker"))
+	require.Equal(t, "api-secure", trimHost("api-worker-secure", "worker"))
+	require.Equal(t, "api-other", trimHost("api-other", "worker"))
+}
+
+func TestMerger(t *testing.T) {
+	names := []string{"one", "two", "three"}
+	results := []*dynamic.Configuration{
+		endpointConfig("one", "Host(`api.example.com`)"),
+		endpointConfig("two", "Host(`api.example.com`)"),
+		endpointConfig("three", "Host(`other.example.com`)"),
+	}
+
+	var plain *merger
+	val := plain.merge(names, results)
+	require.Len(t, val.HTTP.Routers, 9)
+
+	merge := &merger{aggregate: true, weights: []int{1, 1, 1}}
+	val = merge.merge(names, results)
+	require.Len(t, val.HTTP.Routers, 7)
+	require.NotContains(t, val.HTTP.Routers, "api-one")
+	require.NotContains(t, val.HTTP.Routers, "api-two-secure")
+	require.Contains(t, val.HTTP.Routers, "api-three")
+	require.Contains(t, val.HTTP.Routers, "local-one")
+	require.Equal(t, &dynamic.Router{Service: "api", Rule: "Host(`api.example.com`)", Priority: 3},
+		val.HTTP.Routers["api"])
+	require.Equal(t, "api", val.HTTP.Routers["api-secure"].Service)
+	require.Equal(t, &dynamic.Service{LoadBalancer: &dynamic.ServersLoadBalancer{
+		Servers: []dynamic.Server{{URL: "http://one:80"}, {URL: "http://two:80"}},
+	}}, val.HTTP.Services["api"])
+
+	one, two := 3, 2
+	merge = &merger{aggregate: true, sticky: "worker", weights: []int{3, 1, 1}}
+	results[1].HTTP.Services["api-two"] = &dynamic.Service{Weighted: &dynamic.WeightedRoundRobin{
+		Services: []dynamic.WRRService{{Name: "api-two-lb", Weight: &two}},
+	}}
+	results[1].HTTP.Services["api-two-lb"] = &dynamic.Service{LoadBalancer: &dynamic.ServersLoadBalancer{
+		Servers: []dynamic.Server{{URL: "http://two:80"}},
+	}}
+
+	val = merge.merge(names, results)
+	require.Equal(t, &dynamic.Service{Weighted: &dynamic.WeightedRoundRobin{
+		Services: []dynamic.WRRService{{Name: "api-one", Weight: &one}, {Name: "api-two-lb", Weight: &two}},
+		Sticky:   &dynamic.Sticky{Cookie: &dynamic.Cookie{Name: "worker"}},
+	}}, val.HTTP.Services["api"])
+}
+
+func TestMerger_nameTaken(t *testing.T) {
+	names := []string{"one", "two"}
+	results := []*dynamic.Configuration{
+		endpointConfig("one", "Host(`api.example.com`)"),
+		endpointConfig("two", "Host(`api.example.com`)"),
+	}
+
+	results[0].HTTP.Routers["api"] = &dynamic.Router{Service: "api-one", Rule: "Host(`taken.example.com`)"}
+
+	merge := &merger{aggregate: true, weights: []int{1, 1}}
+	val := merge.merge(names, results)
+	require.Contains(t, val.HTTP.Routers, "api-one")
+	require.Contains(t, val.HTTP.Routers, "api-two")
+	require.Contains(t, val.HTTP.Routers, "api-secure")
+}
+
+func TestMerger_transport(t *testing.T) {
+	names := []string{"one", "two"}
+	results := []*dynamic.Configuration{
+		endpointConfig("one", "Host(`api.example.com`)"),
+		endpointConfig("two", "Host(`api.example.com`)"),
+	}
+
+	for i, name := range names {
+		results[i].HTTP.Services["api-"+name].LoadBalancer.ServersTransport = "api-" + name
+		results[i].HTTP.ServersTransports = map[string]*dynamic.ServersTransport{
+			"api-" + name: {ServerName: "api.example.com"},
+		}
+	}
+
+	weight := 1
+	merge := &merger{aggregate: true, weights: []int{1, 1}}
+	val := merge.merge(names, results)
+	require.Len(t, val.HTTP.ServersTransports, 2)
+	require.Equal(t, &dynamic.Service{Weighted: &dynamic.WeightedRoundRobin{
+		Services: []dynamic.WRRService{{Name: "api-one", Weight: &weight}, {Name: "api-two", Weight: &weight}},
+	}}, val.HTTP.Services["api"])
+}
+
+func TestMerger_middlewares(t *testing.T) {
+	names := []string{"one", "two", "three"}
+	results := []*dynamic.Configuration{
+		endpointConfig("one", "Host(`api.example.com`)"),
+		endpointConfig("two", "Host(`api.example.com`)"),
+		endpointConfig("three", "Host(`api.example.com`)"),
+	}
+
+	for i, name := range names[:2] {
+		results[i].HTTP.Routers["api-"+name].Middlewares = []string{"auth-docker-" + name}
+	}
+
+	results[2].HTTP.Routers["api-three"].Middlewares = []string{"headers-docker-three"}
+
+	merge := &merger{aggregate: true, weights: []int{1, 1, 1}}
+	val := merge.merge(names, results)
+	require.Equal(t, []string{"auth-docker-one"}, val.HTTP.Routers["api"].Middlewares)
+	require.Equal(t, []string{"headers-docker-three"}, val.HTTP.Routers["api-three"].Middlewares)
+	require.Equal(t, &dynamic.Service{LoadBalancer: &dynamic.ServersLoadBalancer{
+		Servers: []dynamic.Server{{URL: "http://one:80"}, {URL: "http://two:80"}},
+	}}, val.HTTP.Services["api"])
+}
@@ -0,0 +1,87 @@
+package traefik_provider
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/traefik/genconf/dynamic"
+)
+
+// claim records the endpoint owning a hostname and when it last advertised it.
+type claim struct {
+	owner    string
+	lastSeen time.Time
+}
+
+// ownership gives every hostname to the endpoint that published it first.
+// Other endpoints are refused until the owner stops advertising the hostname
+// for longer than the grace period.
+type ownership struct {
+	grace time.Duration
+	now   func() time.Time
+	hosts map[string]*claim
+}
+
+func newOwnership(grace time.Duration) *ownership {
+	return &ownership{grace: grace, now: time.Now, hosts: make(map[string]*claim)}
+}
+
+// apply updates the owners from the routes in val and drops routes claiming
+// hostnames owned by another endpoint. It returns the refusals.
+func (o *ownership) apply(val *dynamic.Configuration, names []string, results []*dynamic.Configuration) []string {
+	now := o.now()
+	routes := collectRoutes(*val, results)
+
+	// routes are ordered by endpoint, so the first one listed wins a tie
+	advertised := make(map[string][]string)
+	for _, item := range routes {
+		for _, host := range item.hosts {
+			if name := names[item.index]; !slices.Contains(advertised[host], name) {
+				advertised[host] = append(advertised[host], name)
+			}
+		}
+	}
+
+	for host, item := range o.hosts {
+		if slices.Contains(advertised[host], item.owner) {
+			item.lastSeen = now
+		} else if idle := now.Sub(item.lastSeen); idle >= o.grace {
+			log.Printf("host %q is released by %q after %s", host, item.owner, idle.Round(time.Second))
+
+			delete(o.hosts, host)
+		}
+	}
+
+	for host, owners := range advertised {
+		if _, ok := o.hosts[host]; !ok {
+			log.Printf("host %q is owned by %q", host, owners[0])
+
+			o.hosts[host] = &claim{owner: owners[0], lastSeen: now}
+		}
+	}
+
+	var out []string
+	for _, item := range routes {
+		name := names[item.index]
+		for _, host := range item.hosts {
+			if owner := o.hosts[host].owner; owner != name {
+				out = append(out, fmt.Sprintf(
+					"refuse %s %q(client:%q): host %q is owned by %q",
+					item.kind,
+					item.name,
+					name,
+					host,
+					owner,
+				))
+
+				item.drop(val)
+
+				break
+			}
+		}
+	}
+
+	return out
+}
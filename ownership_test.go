@@ -0,0 +1,119 @@
+package traefik_provider
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/genconf/dynamic"
+)
+
+func TestOwnership(t *testing.T) {
+	now := time.Now()
+
+	owners := newOwnership(time.Minute)
+	owners.now = func() time.Time { return now }
+
+	merge := &merger{weights: []int{1, 1}, owners: owners}
+	names := []string{"one", "two"}
+
+	// the second endpoint publishes the host first
+	val := merge.merge(names, []*dynamic.Configuration{nil, routerConfig("app-two", "Host(`app.example.com`)", 0)})
+	require.Contains(t, val.HTTP.Routers, "app-two")
+	require.Equal(t, "two", owners.hosts["app.example.com"].owner)
+
+	results := []*dynamic.Configuration{
+		routerConfig("app-one", "Host(`app.example.com`) && PathPrefix(`/steal`)", 0),
+		routerConfig("app-two", "Host(`app.example.com`)", 0),
+	}
+
+	now = now.Add(time.Hour)
+	val = merge.merge(names, results)
+	require.NotContains(t, val.HTTP.Routers, "app-one")
+	require.Contains(t, val.HTTP.Routers, "app-two")
+	require.Equal(t, []string{
+		`refuse router "app-one"(client:"one"): host "app.example.com" is owned by "two"`,
+	}, merge.conflicts)
+
+	// the owner stops advertising the host, the grace period keeps it
+	results[1] = nil
+	now = now.Add(time.Second * 30)
+	val = merge.merge(names, results)
+	require.NotContains(t, val.HTTP.Routers, "app-one")
+
+	now = now.Add(time.Second * 31)
+	val = merge.merge(names, results)
+	require.Contains(t, val.HTTP.Routers, "app-one")
+	require.Equal(t, "one", owners.hosts["app.example.com"].owner)
+	require.Empty(t, merge.conflicts)
+}
+
+func TestOwnership_tie(t *testing.T) {
+	merge := &merger{weights: []int{1, 1}, owners: newOwnership(0), aggregate: true}
+	names := []string{"one", "two"}
+
+	val := merge.merge(names, []*dynamic.Configuration{
+		routerConfig("app-one", "Host(`app.example.com`)", 0),
+		routerConfig("app-two", "Host(`app.example.com`)", 0),
+	})
+	require.Len(t, val.HTTP.Routers, 1)
+	require.Contains(t, val.HTTP.Routers, "app-one", "aggregation does not bypass ownership")
+	require.Equal(t, "one", merge.owners.hosts["app.example.com"].owner)
+}
+
+func TestOwnership_failedPoll(t *testing.T) {
+	now := time.Now()
+
+	owners := newOwnership(time.Second * 15)
+	owners.now = func() time.Time { return now }
+
+	merge := &merger{weights: []int{1, 1}, owners: owners}
+	names := []string{"one", "two"}
+
+	val := merge.merge(names, []*dynamic.Configuration{nil, routerConfig("app-two", "Host(`app.example.com`)", 0)})
+	require.Contains(t, val.HTTP.Routers, "app-two")
+
+	// a single failed poll of the owner does not hand the host over
+	now = now.Add(time.Second * 5)
+	val = merge.merge(names, []*dynamic.Configuration{routerConfig("app-one", "Host(`app.example.com`)", 0), nil})
+	require.NotContains(t, val.HTTP.Routers, "app-one")
+	require.Equal(t, "two", owners.hosts["app.example.com"].owner)
+
+	now = now.Add(time.Second * 5)
+	val = merge.merge(names, []*dynamic.Configuration{
+		routerConfig("app-one", "Host(`app.example.com`)", 0),
+		routerConfig("app-two", "Host(`app.example.com`)", 0),
+	})
+	require.NotContains(t, val.HTTP.Routers, "app-one")
+	require.Contains(t, val.HTTP.Routers, "app-two")
+}
+
+func TestNewMerger_ownershipGrace(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	addr, ok := srv.Listener.Addr().(*net.TCPAddr)
+	require.True(t, ok)
+
+	cfg := Config{
+		ConnTimeout:   "15s",
+		PollInterval:  "5s",
+		HostOwnership: true,
+		Endpoints: []Endpoint{
+			{Host: addr.IP.String(), API: addr.Port, WEB: addr.Port},
+			{Host: addr.IP.String(), API: addr.Port, WEB: addr.Port, PollInterval: "1s", MaxBackoff: "20s"},
+		},
+	}
+
+	p, err := New(t.Context(), &cfg, "test")
+	require.NoError(t, err)
+	require.Equal(t, time.Minute, newMerger(p.config, p.clients).owners.grace)
+
+	p.config.OwnershipGrace = time.Second
+	require.Equal(t, time.Second, newMerger(p.config, p.clients).owners.grace)
+}
@@ -44,98 +44,187 @@ func (p *Provider) Init() error {
 	return nil
 }
 
-func fetchConfig(top context.Context, out chan<- json.Marshaler, clients []*internal.Client) error {
-	merge := make(chan *dynamic.Configuration, 2)
-	defer close(merge)
+type update struct {
+	index  int
+	config *dynamic.Configuration
+	err    error
+}
 
-	run := newRunner(top)
-	for _, client := range clients {
-		run.Go(func(ctx context.Context) error {
-			if err := client.FetchRaw(ctx, merge); err != nil {
-				log.Printf("could not fetch(client:%q): %s", client.Endpoint(), err)
+// poll fetches a single endpoint on its own schedule and reports every result.
+func poll(top context.Context, index int, client *internal.Client, updates chan<- update) error {
+	tick := time.NewTimer(time.Microsecond)
+	defer tick.Stop()
 
-				return err
+	var failures int
+	for {
+		select {
+		case <-top.Done():
+			return nil
+		case <-tick.C:
+			merge := make(chan *dynamic.Configuration, 1)
+
+			ctx, cancel := context.WithTimeout(top, client.Timeout())
+			err := client.FetchRaw(ctx, merge)
+			cancel()
+
+			// a cached config is still served, but the endpoint is unreachable
+			if err != nil && !errors.Is(err, internal.ErrEmptyResponse) {
+				failures++
+			} else {
+				failures = 0
 			}
 
-			return nil
-		})
+			select {
+			case <-top.Done():
+				return nil
+			case updates <- update{index: index, config: <-merge, err: err}:
+			}
+
+			tick.Reset(client.NextPoll(failures))
+		}
 	}
+}
 
-	run.Go(func(ctx context.Context) error {
-		var (
-			cnt int
-			val dynamic.Configuration
-		)
+// aggregate keeps the latest result of every endpoint and re-emits the merged
+// configuration whenever one of them changes. The first emission waits until
+// every endpoint has reported once, so startup does not publish partial routes.
+func aggregate(top context.Context, emit *emitter, clients []*internal.Client, updates <-chan update) error {
+	names := make([]string, 0, len(clients))
+	for _, client := range clients {
+		names = append(names, client.Endpoint())
+	}
 
-	loop:
-		for {
-			if cnt == len(clients) {
-				break loop
+	pending := len(clients)
+	reported := make([]bool, len(clients))
+	results := make([]*dynamic.Configuration, len(clients))
+	for {
+		select {
+		case <-top.Done():
+			return nil
+		case msg := <-updates:
+			results[msg.index] = msg.config
+			// the fallback logs when a cached config is served
+			if msg.err != nil && !errors.Is(msg.err, internal.ErrStaleConfig) {
+				log.Printf("could not fetch(client:%q): %s", names[msg.index], msg.err)
 			}
 
-			select {
-			case <-ctx.Done():
-				break loop
-			case msg := <-merge:
-				cnt++
-
-				if msg == nil || msg.HTTP == nil {
-					continue loop
-				}
-
-				if val.HTTP == nil {
-					val.HTTP = &dynamic.HTTPConfiguration{
-						Routers:     make(map[string]*dynamic.Router),
-						Services:    make(map[string]*dynamic.Service),
-						Middlewares: make(map[string]*dynamic.Middleware),
-					}
-				}
-
-				for key, item := range msg.HTTP.Routers {
-					val.HTTP.Routers[key] = item
-				}
-
-				for key, item := range msg.HTTP.Services {
-					val.HTTP.Services[key] = item
-				}
-
-				for key, item := range msg.HTTP.Middlewares {
-					val.HTTP.Middlewares[key] = item
-				}
+			if !reported[msg.index] {
+				reported[msg.index] = true
+				pending--
+			}
+
+			if pending > 0 {
+				continue
+			}
+
+			if _, err := emit.emit(names, results); err != nil {
+				log.Print(err)
 			}
 		}
+	}
+}
 
-		out <- dynamic.JSONPayload{Configuration: &val}
+// mergeHTTP adds msg to val, entries already added by a previous endpoint win.
+func mergeHTTP(val *dynamic.Configuration, msg *dynamic.HTTPConfiguration) {
+	if msg == nil {
+		return
+	}
 
-		return nil
-	})
+	if val.HTTP == nil {
+		val.HTTP = &dynamic.HTTPConfiguration{
+			Routers:     make(map[string]*dynamic.Router),
+			Services:    make(map[string]*dynamic.Service),
+			Middlewares: make(map[string]*dynamic.Middleware),
+		}
+	}
 
-	if err := run.Wait(); err != nil && !errors.Is(err, context.Canceled) {
-		return err
+	for key, item := range msg.Routers {
+		if _, ok := val.HTTP.Routers[key]; !ok {
+			val.HTTP.Routers[key] = item
+		}
 	}
 
-	return nil
+	for key, item := range msg.Services {
+		if _, ok := val.HTTP.Services[key]; !ok {
+			val.HTTP.Services[key] = item
+		}
+	}
+
+	for key, item := range msg.Middlewares {
+		if _, ok := val.HTTP.Middlewares[key]; !ok {
+			val.HTTP.Middlewares[key] = item
+		}
+	}
+
+	for key, item := range msg.ServersTransports {
+		if val.HTTP.ServersTransports == nil {
+			val.HTTP.ServersTransports = make(map[string]*dynamic.ServersTransport)
+		}
+
+		if _, ok := val.HTTP.ServersTransports[key]; !ok {
+			val.HTTP.ServersTransports[key] = item
+		}
+	}
 }
 
-func (p *Provider) Provide(out chan<- json.Marshaler) error {
-	p.routine.Go(func(top context.Context) error {
-		tick := time.NewTimer(time.Microsecond)
-		defer tick.Stop()
+func mergeTCP(val *dynamic.Configuration, msg *dynamic.TCPConfiguration) {
+	if msg == nil {
+		return
+	}
 
-		for {
-			select {
-			case <-top.Done():
-				return nil
-			case <-tick.C:
-				ctx, cancel := context.WithTimeout(top, p.config.PollInterval)
-				if err := fetchConfig(ctx, out, p.clients); err != nil {
-					log.Print(err)
-				}
-				cancel()
-
-				tick.Reset(p.config.PollInterval)
-			}
+	if val.TCP == nil {
+		val.TCP = &dynamic.TCPConfiguration{
+			Routers:  make(map[string]*dynamic.TCPRouter),
+			Services: make(map[string]*dynamic.TCPService),
 		}
+	}
+
+	for key, item := range msg.Routers {
+		if _, ok := val.TCP.Routers[key]; !ok {
+			val.TCP.Routers[key] = item
+		}
+	}
+
+	for key, item := range msg.Services {
+		if _, ok := val.TCP.Services[key]; !ok {
+			val.TCP.Services[key] = item
+		}
+	}
+}
+
+func mergeUDP(val *dynamic.Configuration, msg *dynamic.UDPConfiguration) {
+	if msg == nil {
+		return
+	}
+
+	if val.UDP == nil {
+		val.UDP = &dynamic.UDPConfiguration{
+			Routers:  make(map[string]*dynamic.UDPRouter),
+			Services: make(map[string]*dynamic.UDPService),
+		}
+	}
+
+	for key, item := range msg.Routers {
+		if _, ok := val.UDP.Routers[key]; !ok {
+			val.UDP.Routers[key] = item
+		}
+	}
+
+	for key, item := range msg.Services {
+		if _, ok := val.UDP.Services[key]; !ok {
+			val.UDP.Services[key] = item
+		}
+	}
+}
+
+func (p *Provider) Provide(out chan<- json.Marshaler) error {
+	updates := make(chan update, len(p.clients))
+	for i, client := range p.clients {
+		p.routine.Go(func(top context.Context) error { return poll(top, i, client, updates) })
+	}
+
+	p.routine.Go(func(top context.Context) error {
+		return aggregate(top, newEmitter(out, newMerger(p.config, p.clients)), p.clients, updates)
 	})
 
 	return nil
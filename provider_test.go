@@ -8,12 +8,15 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/traefik/genconf/dynamic"
+
+	"github.com/im-kulikov/traefik-provider/internal"
 )
 
 func catchError(args ...any) error {
@@ -71,19 +74,21 @@ func TestProvider(t *testing.T) {
 			Configuration: &dynamic.Configuration{
 				HTTP: &dynamic.HTTPConfiguration{
 					Routers: map[string]*dynamic.Router{
-						"whoami-" + addr.IP.String(): {
+						"whoami-docker-" + addr.IP.String(): {
 							Middlewares: []string{"http2https"},
-							Service:     "whoami-" + addr.IP.String(),
+							Service:     "whoami-docker-" + addr.IP.String(),
 							Rule:        "Host(`whoami.example.com`)",
+							Priority:    21,
 						},
-						"whoami-" + addr.IP.String() + "-secure": {
-							Service: "whoami-" + addr.IP.String(),
-							Rule:    "Host(`whoami.example.com`)",
-							TLS:     &dynamic.RouterTLSConfig{CertResolver: resolver},
+						"whoami-docker-" + addr.IP.String() + "-secure": {
+							Service:  "whoami-docker-" + addr.IP.String(),
+							Rule:     "Host(`whoami.example.com`)",
+							Priority: 21,
+							TLS:      &dynamic.RouterTLSConfig{CertResolver: resolver},
 						},
 					},
 					Services: map[string]*dynamic.Service{
-						"whoami-" + addr.IP.String(): {
+						"whoami-docker-" + addr.IP.String(): {
 							LoadBalancer: &dynamic.ServersLoadBalancer{
 								Servers: []dynamic.Server{{URL: (&url.URL{
 									Scheme: "http",
@@ -177,3 +182,129 @@ func TestProvider_empty(t *testing.T) {
 	require.NoError(t, err)
 	require.JSONEq(t, `{}`, string(result))
 }
+
+func TestProvider_isolated(t *testing.T) {
+	data, err := os.ReadFile("fixtures/jaeger-api-rawdata.json")
+	require.NoError(t, err)
+
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+
+		assert.NoError(t, catchError(w.Write(data)))
+	}))
+
+	var broken atomic.Bool
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if broken.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	good, ok := healthy.Listener.Addr().(*net.TCPAddr)
+	require.True(t, ok)
+
+	bad, ok := failing.Listener.Addr().(*net.TCPAddr)
+	require.True(t, ok)
+
+	ctx, cancel := context.WithTimeout(t.Context(), time.Second)
+	defer cancel()
+
+	cfg := Config{
+		ConnTimeout:  "15s",
+		PollInterval: "5s",
+		Endpoints: []Endpoint{
+			{Host: "localhost", API: bad.Port, WEB: bad.Port, PollInterval: "10ms", MaxBackoff: "50ms"},
+			{Host: good.IP.String(), API: good.Port, WEB: good.Port},
+		},
+	}
+
+	p, err := New(ctx, &cfg, "test")
+	require.NoError(t, err)
+	require.NoError(t, p.Init())
+
+	broken.Store(true)
+
+	out := make(chan json.Marshaler, 100)
+	require.NoError(t, p.Provide(out))
+
+	select {
+	case <-ctx.Done():
+		t.Fatal("no response")
+	case result := <-out:
+		payload, ok := result.(dynamic.JSONPayload)
+		require.True(t, ok)
+		require.NotNil(t, payload.HTTP)
+		require.Len(t, payload.HTTP.Routers, 1)
+		require.Contains(t, payload.HTTP.Routers, "whoami-docker-"+good.IP.String())
+	}
+
+	// the failing endpoint keeps being polled, but nothing changes
+	time.Sleep(time.Millisecond * 100)
+	require.Empty(t, out)
+	require.ErrorIs(t, p.Stop(), context.Canceled)
+}
+
+func TestPoll_staleBackoff(t *testing.T) {
+	data, err := os.ReadFile("fixtures/jaeger-api-rawdata.json")
+	require.NoError(t, err)
+
+	var (
+		calls  atomic.Int32
+		broken atomic.Bool
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if broken.Load() {
+			calls.Add(1)
+			w.WriteHeader(http.StatusBadGateway)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+
+		assert.NoError(t, catchError(w.Write(data)))
+	}))
+
+	addr, ok := srv.Listener.Addr().(*net.TCPAddr)
+	require.True(t, ok)
+
+	cfg := Config{
+		ConnTimeout:  "15s",
+		PollInterval: "5s",
+		MaxStaleness: "1m",
+		Endpoints: []Endpoint{
+			{Host: addr.IP.String(), API: addr.Port, WEB: addr.Port, PollInterval: "10ms", MaxBackoff: "1s"},
+		},
+	}
+
+	p, err := New(t.Context(), &cfg, "test")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(t.Context(), time.Millisecond*300)
+	defer cancel()
+
+	updates := make(chan update)
+	go func() { assert.NoError(t, poll(ctx, 0, p.clients[0], updates)) }()
+
+	// the first poll succeeds, the next ones serve the cached config
+	require.NotNil(t, (<-updates).config)
+
+	broken.Store(true)
+	for {
+		select {
+		case <-ctx.Done():
+			// without backoff the endpoint would be polled about 30 times
+			require.Less(t, calls.Load(), int32(10))
+
+			return
+		case msg := <-updates:
+			require.NotNil(t, msg.config)
+			require.ErrorIs(t, msg.err, internal.ErrStaleConfig)
+		}
+	}
+}